@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/follow1123/sing-box-ctl/config"
+	"github.com/follow1123/sing-box-ctl/converter"
+	P "github.com/follow1123/sing-box-ctl/provider"
+	R "github.com/follow1123/sing-box-ctl/rule"
+)
+
+// 获取配置模板，优先使用 provider 设置的模板，其次是配置目录下的模板，都不存在时返回空使用内置模板
+func loadTemplate(conf *config.Config, d *P.Data) (string, error) {
+	if d != nil && d.Template != "" {
+		data, err := os.ReadFile(d.Template)
+		if err != nil {
+			return "", fmt.Errorf("read template '%s' error:\n\t%w", d.Template, err)
+		}
+		return string(data), nil
+	}
+	data, err := os.ReadFile(conf.TemplatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("read template '%s' error:\n\t%w", conf.TemplatePath(), err)
+	}
+	return string(data), nil
+}
+
+// 转换订阅时使用的选项，provider 和 d 可以为 nil
+func convertOptions(conf *config.Config, provider *P.Provider, d *P.Data) ([]converter.Option, error) {
+	tmpl, err := loadTemplate(conf, d)
+	if err != nil {
+		return nil, err
+	}
+	opts := []converter.Option{
+		converter.WithLoader(P.DataFromSource),
+		converter.WithTemplate(tmpl),
+	}
+	if d != nil {
+		opts = append(opts, converter.WithNodeFilter(nodeFilter(d)), converter.WithClashSettings(d.ClashSettings))
+	}
+	rules, err := R.New(conf.ConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	list, err := rules.List()
+	if err != nil {
+		return nil, err
+	}
+	var userRules []converter.UserRule
+	for _, r := range list {
+		userRules = append(userRules, converter.UserRule{Type: r.Type, Value: r.Value, Outbound: r.Outbound})
+	}
+	opts = append(opts, converter.WithUserRules(userRules))
+	if provider != nil {
+		regions, exists, err := provider.Regions()
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			var converted []converter.Region
+			for _, r := range regions {
+				converted = append(converted, converter.Region{Name: r.Name, Emoji: r.Emoji, Keywords: r.Keywords, Codes: r.Codes})
+			}
+			opts = append(opts, converter.WithRegions(converted))
+		}
+	}
+	return opts, nil
+}
+
+// provider 的节点过滤和重命名规则
+func nodeFilter(d *P.Data) converter.NodeFilter {
+	filter := converter.NodeFilter{Include: d.Include, Exclude: d.Exclude}
+	for _, r := range d.Rename {
+		filter.Rename = append(filter.Rename, converter.RenameRule{Pattern: r.Pattern, Replace: r.Replace})
+	}
+	return filter
+}
+
+// 合并多个订阅时归档每个订阅的原始配置，恢复时重新合并
+type mergedArchive struct {
+	Merged []mergedSource `json:"merged"`
+}
+
+type mergedSource struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// 归档的内容是合并的订阅时返回每个订阅，普通订阅返回 false
+func decodeMergedArchive(data []byte) ([]mergedSource, bool) {
+	var archive mergedArchive
+	if err := json.Unmarshal(data, &archive); err != nil || len(archive.Merged) == 0 {
+		return nil, false
+	}
+	return archive.Merged, true
+}
+
+// 转换为合并使用的订阅，使用 provider 当前的节点过滤规则，provider 不存在时不过滤
+func mergeSources(provider *P.Provider, archived []mergedSource) []converter.Source {
+	var sources []converter.Source
+	for _, s := range archived {
+		source := converter.Source{Name: s.Name, Data: s.Data}
+		if provider != nil {
+			if d, err := provider.Get(s.Name); err == nil {
+				source.Filter = nodeFilter(d)
+			}
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:          "export",
+	Short:        "Export config to other formats",
+	SilenceUsage: true, // 关闭错误时的帮助信息
+	GroupID:      cmdGrpDefault,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
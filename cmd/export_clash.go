@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/follow1123/sing-box-ctl/config"
+	"github.com/follow1123/sing-box-ctl/converter"
+	"github.com/spf13/cobra"
+)
+
+var exportClashFlagOutput string
+
+var exportClashCmd = &cobra.Command{
+	Use:          "clash [flags]",
+	Short:        "Export current config as clash config",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true, // 关闭错误时的帮助信息
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.Default()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(conf.SingBoxConfigPath())
+		if err != nil {
+			return fmt.Errorf("read config '%s' error:\n\t%w", conf.SingBoxConfigPath(), err)
+		}
+		clashData, report, err := converter.ToClash(data)
+		if err != nil {
+			return err
+		}
+		// 忽略的内容输出到 stderr，不影响输出到 stdout 的配置
+		for _, msg := range report.Messages() {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+		if exportClashFlagOutput == "" || exportClashFlagOutput == "-" {
+			_, err := os.Stdout.Write(clashData)
+			return err
+		}
+		if err := os.WriteFile(exportClashFlagOutput, clashData, 0660); err != nil {
+			return fmt.Errorf("export clash config to '%s' error:\n\t%w", exportClashFlagOutput, err)
+		}
+		cmd.Printf("clash config exported to '%s'\n", exportClashFlagOutput)
+		return nil
+	},
+}
+
+func init() {
+	exportClashCmd.Flags().StringVarP(&exportClashFlagOutput, "output", "o", "", "output path (default stdout)")
+
+	exportCmd.AddCommand(exportClashCmd)
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/follow1123/sing-box-ctl/config"
 	P "github.com/follow1123/sing-box-ctl/provider"
@@ -58,3 +60,18 @@ var providerCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(providerCmd)
 }
+
+// 设置 provider 的模板，保存为绝对路径
+func setProviderTemplate(provider *P.Provider, name string, template string) error {
+	if template != "" {
+		absPath, err := filepath.Abs(template)
+		if err != nil {
+			return fmt.Errorf("resolve template path '%s' error:\n\t%w", template, err)
+		}
+		if _, err := os.Stat(absPath); err != nil {
+			return fmt.Errorf("check template '%s' error:\n\t%w", absPath, err)
+		}
+		template = absPath
+	}
+	return provider.SetTemplate(name, template)
+}
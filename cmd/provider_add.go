@@ -8,6 +8,7 @@ import (
 
 var (
 	providerAddFlagSetDefault bool
+	providerAddFlagTemplate   string
 )
 
 var providerAddCmd = &cobra.Command{
@@ -29,6 +30,11 @@ var providerAddCmd = &cobra.Command{
 		if err := provider.Add(name, url); err != nil {
 			return err
 		}
+		if cmd.Flags().Changed("template") {
+			if err := setProviderTemplate(provider, name, providerAddFlagTemplate); err != nil {
+				return err
+			}
+		}
 		if providerAddFlagSetDefault {
 			if err := provider.SetDefault(name); err != nil {
 				return err
@@ -43,6 +49,7 @@ var providerAddCmd = &cobra.Command{
 
 func init() {
 	providerAddCmd.Flags().BoolVarP(&providerAddFlagSetDefault, "set-default", "d", false, "set this provider to the default")
+	providerAddCmd.Flags().StringVarP(&providerAddFlagTemplate, "template", "t", "", "config template path of this provider, empty to use default template")
 
 	providerCmd.AddCommand(providerAddCmd)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
@@ -16,13 +18,19 @@ import (
 var (
 	providerFetchFlagFormat  bool
 	providerFetchFlagRestart bool
+	providerFetchFlagReport  string
+	providerFetchFlagMerge   []string
+	providerFetchFlagAll     bool
 )
 
 var providerFetchCmd = &cobra.Command{
 	Use:          "fetch",
-	Short:        "Fetch and convert config from default provider",
+	Short:        "Fetch and convert config from default provider or merge multiple providers",
 	SilenceUsage: true, // 关闭错误时的帮助信息
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkReportFormat(providerFetchFlagReport); err != nil {
+			return err
+		}
 		conf, err := config.Default()
 		if err != nil {
 			return err
@@ -31,20 +39,42 @@ var providerFetchCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		d, err := provider.GetDefault()
-		if err != nil {
-			return err
+		merged := providerFetchFlagAll || len(providerFetchFlagMerge) > 0
+		var data, newConfig []byte
+		var report *converter.Report
+		if merged {
+			providers, err := mergedProviders(provider)
+			if err != nil {
+				return err
+			}
+			newConfig, report, data, err = fetchMerged(conf, provider, providers)
+			if err != nil {
+				return err
+			}
+		} else {
+			d, err := provider.GetDefault()
+			if err != nil {
+				return err
+			}
+			// 下载远程配置
+			data, err = P.DataFromSource(d.Url)
+			if err != nil {
+				return err
+			}
+			// 转换成 sing-box 配置
+			opts, err := convertOptions(conf, provider, d)
+			if err != nil {
+				return err
+			}
+			newConfig, report, err = converter.Convert(data, opts...)
+			if err != nil {
+				return err
+			}
 		}
-		url := d.Url
-
-		// 下载远程配置
-		data, err := P.DataFromSource(url)
-		if err != nil {
+		if err := printReport(os.Stdout, report, providerFetchFlagReport); err != nil {
 			return err
 		}
-		// 转换成 sing-box 配置
-		newConfig, err := converter.Convert(data)
-		if err != nil {
+		if err := printRenamed(os.Stderr, report); err != nil {
 			return err
 		}
 
@@ -55,7 +85,9 @@ var providerFetchCmd = &cobra.Command{
 			// 文件不存在直接作为最终的配置
 			finalConfig = newConfig
 		} else {
-			if err := updater.Upgrade(newConfig, providerFetchFlagFormat); err != nil {
+			// 只使用订阅中设置了的端口和监听地址，其他入站设置保留原配置中的值
+			port, allowLAN := report.ListenSettings()
+			if err := updater.UpgradeWithListen(newConfig, providerFetchFlagFormat, port, allowLAN); err != nil {
 				return err
 			}
 			finalConfig = updater.Data()
@@ -73,7 +105,7 @@ var providerFetchCmd = &cobra.Command{
 			return fmt.Errorf("save final config error:\n\t%w", err)
 		}
 
-		// 归档下载的原始配置文件
+		// 归档下载的原始配置文件，合并多个订阅时归档所有订阅
 		archiver, err := A.New(conf.ArchiveDir())
 		if err != nil {
 			return err
@@ -91,9 +123,62 @@ var providerFetchCmd = &cobra.Command{
 	},
 }
 
+// 需要合并的订阅，第一个订阅的模板和设置用于生成配置
+func mergedProviders(provider *P.Provider) ([]P.Data, error) {
+	var providers []P.Data
+	if providerFetchFlagAll {
+		list, err := provider.List()
+		if err != nil {
+			return nil, err
+		}
+		providers = list
+	} else {
+		for _, name := range providerFetchFlagMerge {
+			d, err := provider.Get(name)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, *d)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("no providers to merge")
+	}
+	return providers, nil
+}
+
+// 下载并合并多个订阅，使用第一个订阅的模板，同时返回需要归档的所有订阅的原始配置
+func fetchMerged(conf *config.Config, provider *P.Provider, providers []P.Data) ([]byte, *converter.Report, []byte, error) {
+	var archive mergedArchive
+	for _, d := range providers {
+		data, err := P.DataFromSource(d.Url)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("fetch provider '%s' error:\n\t%w", d.Name, err)
+		}
+		archive.Merged = append(archive.Merged, mergedSource{Name: d.Name, Data: data})
+	}
+	archiveData, err := json.Marshal(archive)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("marshal merged providers error:\n\t%w", err)
+	}
+	opts, err := convertOptions(conf, provider, &providers[0])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	newConfig, report, err := converter.ConvertMerged(mergeSources(provider, archive.Merged), opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return newConfig, report, archiveData, nil
+}
+
 func init() {
 	providerFetchCmd.Flags().BoolVarP(&providerFetchFlagFormat, "format", "f", false, "format config")
 	providerFetchCmd.Flags().BoolVarP(&providerFetchFlagRestart, "restart", "r", false, "restart service")
+	providerFetchCmd.Flags().StringVar(&providerFetchFlagReport, "report", reportFormatTable, "conversion report format, table or json")
+	providerFetchCmd.Flags().StringSliceVarP(&providerFetchFlagMerge, "merge", "m", nil, "merge the specified providers into one config")
+	providerFetchCmd.Flags().BoolVarP(&providerFetchFlagAll, "all", "a", false, "merge all providers into one config")
+	providerFetchCmd.MarkFlagsMutuallyExclusive("merge", "all")
 
 	providerCmd.AddCommand(providerFetchCmd)
 }
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/follow1123/sing-box-ctl/config"
 	P "github.com/follow1123/sing-box-ctl/provider"
@@ -10,6 +12,11 @@ import (
 
 var (
 	providerUpdateFlagSetDefault bool
+	providerUpdateFlagTemplate   string
+	providerUpdateFlagInclude    []string
+	providerUpdateFlagExclude    []string
+	providerUpdateFlagRename     []string
+	providerUpdateFlagClash      bool
 )
 
 var providerUpdateCmd = &cobra.Command{
@@ -41,6 +48,19 @@ var providerUpdateCmd = &cobra.Command{
 				return err
 			}
 		}
+		if cmd.Flags().Changed("template") {
+			if err := setProviderTemplate(provider, name, providerUpdateFlagTemplate); err != nil {
+				return err
+			}
+		}
+		if err := setProviderNodeFilter(cmd, provider, name); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("clash-settings") {
+			if err := provider.SetClashSettings(name, providerUpdateFlagClash); err != nil {
+				return err
+			}
+		}
 		if providerUpdateFlagSetDefault {
 			if err := provider.SetDefault(name); err != nil {
 				return err
@@ -55,6 +75,70 @@ var providerUpdateCmd = &cobra.Command{
 
 func init() {
 	providerUpdateCmd.Flags().BoolVarP(&providerUpdateFlagSetDefault, "set-default", "d", false, "set this provider to the default")
+	providerUpdateCmd.Flags().StringVarP(&providerUpdateFlagTemplate, "template", "t", "", "config template path of this provider, empty to use default template")
+
+	providerUpdateCmd.Flags().StringArrayVar(&providerUpdateFlagInclude, "include", nil, "regexp of node names to keep, can be repeated, empty to clear")
+	providerUpdateCmd.Flags().StringArrayVar(&providerUpdateFlagExclude, "exclude", nil, "regexp of node names to drop, can be repeated, empty to clear")
+	providerUpdateCmd.Flags().StringArrayVar(&providerUpdateFlagRename, "rename", nil, "rename nodes with 'regexp=>replacement', can be repeated, empty to clear")
+
+	providerUpdateCmd.Flags().BoolVar(&providerUpdateFlagClash, "clash-settings", false, "use dns, hosts, inbound and sniffer settings of the clash config, false to use the template settings")
 
 	providerCmd.AddCommand(providerUpdateCmd)
 }
+
+// 设置 provider 的节点过滤和重命名规则，只修改指定了的参数
+func setProviderNodeFilter(cmd *cobra.Command, provider *P.Provider, name string) error {
+	if cmd.Flags().Changed("include") {
+		patterns, err := checkPatterns(providerUpdateFlagInclude)
+		if err != nil {
+			return err
+		}
+		if err := provider.SetInclude(name, patterns); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("exclude") {
+		patterns, err := checkPatterns(providerUpdateFlagExclude)
+		if err != nil {
+			return err
+		}
+		if err := provider.SetExclude(name, patterns); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("rename") {
+		var rules []P.Rename
+		for _, value := range providerUpdateFlagRename {
+			if value == "" {
+				continue
+			}
+			pattern, replace, found := strings.Cut(value, "=>")
+			if !found {
+				return fmt.Errorf("invalid rename '%s', format is 'regexp=>replacement'", value)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid rename pattern '%s':\n\t%w", pattern, err)
+			}
+			rules = append(rules, P.Rename{Pattern: pattern, Replace: replace})
+		}
+		if err := provider.SetRename(name, rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 检查正则表达式，忽略空字符串
+func checkPatterns(values []string) ([]string, error) {
+	var patterns []string
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if _, err := regexp.Compile(value); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s':\n\t%w", value, err)
+		}
+		patterns = append(patterns, value)
+	}
+	return patterns, nil
+}
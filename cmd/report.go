@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/follow1123/sing-box-ctl/converter"
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	reportFormatTable = "table"
+	reportFormatJson  = "json"
+)
+
+func checkReportFormat(format string) error {
+	if format != reportFormatTable && format != reportFormatJson {
+		return fmt.Errorf("unsupport report format '%s'", format)
+	}
+	return nil
+}
+
+// 输出转换报告，table 格式输出汇总表格，json 格式输出完整的报告
+func printReport(w io.Writer, report *converter.Report, format string) error {
+	switch format {
+	case reportFormatJson:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal report error:\n\t%w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case reportFormatTable:
+		return printReportTable(w, report)
+	default:
+		return fmt.Errorf("unsupport report format '%s'", format)
+	}
+}
+
+// 输出名称重复添加了后缀的节点和分组，和转换报告分开输出
+func printRenamed(w io.Writer, report *converter.Report) error {
+	for _, r := range report.Proxies.Renamed {
+		if _, err := fmt.Fprintf(w, "renamed '%s' to '%s'\n", r.Name, r.Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printReportTable(w io.Writer, report *converter.Report) error {
+	proxyData := [][]string{
+		{"converted", "", strconv.Itoa(len(report.Proxies.Converted))},
+	}
+	if len(report.Proxies.Renamed) > 0 {
+		proxyData = append(proxyData, []string{"renamed", "duplicate name", strconv.Itoa(len(report.Proxies.Renamed))})
+	}
+	reasons := make([]string, 0, len(report.Proxies.Skipped))
+	for reason := range report.Proxies.Skipped {
+		reasons = append(reasons, reason)
+	}
+	slices.Sort(reasons)
+	for _, reason := range reasons {
+		proxyData = append(proxyData, []string{"skipped", reason, strconv.Itoa(len(report.Proxies.Skipped[reason]))})
+	}
+	groupReasons := make([]string, 0, len(report.Groups.Skipped))
+	for reason := range report.Groups.Skipped {
+		groupReasons = append(groupReasons, reason)
+	}
+	slices.Sort(groupReasons)
+	for _, reason := range groupReasons {
+		proxyData = append(proxyData, []string{"skipped group", reason, strconv.Itoa(len(report.Groups.Skipped[reason]))})
+	}
+	proxyTable := tablewriter.NewTable(w, tablewriter.WithEastAsian(false))
+	proxyTable.Header("proxies", "reason", "count")
+	if err := proxyTable.Bulk(proxyData); err != nil {
+		return err
+	}
+	if err := proxyTable.Render(); err != nil {
+		return err
+	}
+
+	var ruleData [][]string
+	for _, typ := range report.RuleTypes() {
+		ruleData = append(ruleData, []string{
+			typ,
+			strconv.Itoa(len(report.Rules.Converted[typ])),
+			strconv.Itoa(len(report.Rules.Ignored[typ])),
+			strconv.Itoa(len(report.Rules.Unsupported[typ])),
+		})
+	}
+	ruleTable := tablewriter.NewTable(w, tablewriter.WithEastAsian(false))
+	ruleTable.Header("rule type", "converted", "ignored", "unsupported")
+	if err := ruleTable.Bulk(ruleData); err != nil {
+		return err
+	}
+	if err := ruleTable.Render(); err != nil {
+		return err
+	}
+
+	var inline, remote int
+	for _, rs := range report.RuleSets {
+		if rs.Type == "inline" {
+			inline++
+		} else {
+			remote++
+		}
+	}
+	if _, err := fmt.Fprintf(w, "rule sets: %d inline, %d remote\n", inline, remote); err != nil {
+		return err
+	}
+	// 只有开启 clash_settings 时才有订阅中的设置
+	if len(report.Settings.Converted) == 0 && report.IgnoredSettings() == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "settings: %s, %d ignored\n", strings.Join(report.Settings.Converted, ", "), report.IgnoredSettings())
+	return err
+}
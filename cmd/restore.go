@@ -8,6 +8,7 @@ import (
 	A "github.com/follow1123/sing-box-ctl/archiver"
 	"github.com/follow1123/sing-box-ctl/config"
 	"github.com/follow1123/sing-box-ctl/converter"
+	P "github.com/follow1123/sing-box-ctl/provider"
 	"github.com/follow1123/sing-box-ctl/service"
 	U "github.com/follow1123/sing-box-ctl/updater"
 	"github.com/spf13/cobra"
@@ -42,7 +43,32 @@ var restoreCmd = &cobra.Command{
 			return fmt.Errorf("read latest archive '%s' error:\n\t%w", latestArchive, err)
 		}
 		// 转换成 sing-box 配置
-		newConfig, err := converter.Convert(data)
+		// 使用默认 provider 的模板，没有默认 provider 时使用配置目录下的模板
+		var d *P.Data
+		// 读取失败时 provider 为 nil
+		provider, err := P.New(conf.ConfigPath())
+		if err == nil {
+			d, _ = provider.GetDefault()
+		}
+		// 合并多个订阅的归档使用第一个订阅的模板和设置重新合并
+		sources, merged := decodeMergedArchive(data)
+		if merged {
+			d = nil
+			if provider != nil {
+				d, _ = provider.Get(sources[0].Name)
+			}
+		}
+		opts, err := convertOptions(conf, provider, d)
+		if err != nil {
+			return err
+		}
+		var newConfig []byte
+		var report *converter.Report
+		if merged {
+			newConfig, report, err = converter.ConvertMerged(mergeSources(provider, sources), opts...)
+		} else {
+			newConfig, report, err = converter.Convert(data, opts...)
+		}
 		if err != nil {
 			return err
 		}
@@ -53,7 +79,9 @@ var restoreCmd = &cobra.Command{
 			// 文件不存在直接作为最终的配置
 			finalConfig = newConfig
 		} else {
-			if err := updater.Upgrade(newConfig, restoreFlagFormat); err != nil {
+			// 只使用订阅中设置了的端口和监听地址，其他入站设置保留原配置中的值
+			port, allowLAN := report.ListenSettings()
+			if err := updater.UpgradeWithListen(newConfig, restoreFlagFormat, port, allowLAN); err != nil {
 				return err
 			}
 			finalConfig = updater.Data()
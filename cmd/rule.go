@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var ruleCmd = &cobra.Command{
+	Use:          "rule",
+	Short:        "Manage custom route rules",
+	SilenceUsage: true, // 关闭错误时的帮助信息
+	GroupID:      cmdGrpDefault,
+}
+
+func init() {
+	rootCmd.AddCommand(ruleCmd)
+}
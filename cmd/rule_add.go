@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/follow1123/sing-box-ctl/config"
+	R "github.com/follow1123/sing-box-ctl/rule"
+	"github.com/spf13/cobra"
+)
+
+var ruleAddCmd = &cobra.Command{
+	Use:   "add [flags] type value outbound",
+	Short: "Add custom route rule",
+	Long: fmt.Sprintf(`Add custom route rule, the rule is placed ahead of the provider rules on every fetch and restore
+
+available types: %s
+outbound is the tag of a node or group, 'reject' to reject the connection`, strings.Join(R.Types, ", ")),
+	Args:         cobra.ExactArgs(3),
+	SilenceUsage: true, // 关闭错误时的帮助信息
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.Default()
+		if err != nil {
+			return err
+		}
+		rules, err := R.New(conf.ConfigPath())
+		if err != nil {
+			return err
+		}
+		if err := rules.Add(R.Rule{Type: args[0], Value: args[1], Outbound: args[2]}); err != nil {
+			return err
+		}
+		return rules.Save()
+	},
+}
+
+func init() {
+	ruleCmd.AddCommand(ruleAddCmd)
+}
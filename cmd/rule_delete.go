@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/follow1123/sing-box-ctl/config"
+	R "github.com/follow1123/sing-box-ctl/rule"
+	"github.com/spf13/cobra"
+)
+
+var ruleDeleteCmd = &cobra.Command{
+	Use:          "delete [flags] index",
+	Short:        "Delete custom route rule by index of 'rule list'",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true, // 关闭错误时的帮助信息
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idx, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid index '%s'", args[0])
+		}
+		conf, err := config.Default()
+		if err != nil {
+			return err
+		}
+		rules, err := R.New(conf.ConfigPath())
+		if err != nil {
+			return err
+		}
+		if err := rules.Delete(idx); err != nil {
+			return err
+		}
+		return rules.Save()
+	},
+}
+
+func init() {
+	ruleCmd.AddCommand(ruleDeleteCmd)
+}
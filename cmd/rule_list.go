@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/follow1123/sing-box-ctl/config"
+	R "github.com/follow1123/sing-box-ctl/rule"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var ruleListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List custom route rules",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true, // 关闭错误时的帮助信息
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.Default()
+		if err != nil {
+			return err
+		}
+		rules, err := R.New(conf.ConfigPath())
+		if err != nil {
+			return err
+		}
+		list, err := rules.List()
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			cmd.Println("no rule use 'rule add' subcommand to add")
+			return nil
+		}
+		var tableData [][]string
+		for i, r := range list {
+			tableData = append(tableData, []string{strconv.Itoa(i + 1), r.Type, r.Value, r.Outbound})
+		}
+		table := tablewriter.NewTable(os.Stdout, tablewriter.WithEastAsian(false))
+		table.Header("index", "type", "value", "outbound")
+		if err := table.Bulk(tableData); err != nil {
+			return err
+		}
+		return table.Render()
+	},
+}
+
+func init() {
+	ruleCmd.AddCommand(ruleListCmd)
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var templateCmd = &cobra.Command{
+	Use:          "template",
+	Short:        "Manage config template",
+	SilenceUsage: true, // 关闭错误时的帮助信息
+	GroupID:      cmdGrpDefault,
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+}
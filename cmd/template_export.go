@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/follow1123/sing-box-ctl/config"
+	"github.com/follow1123/sing-box-ctl/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateExportFlagOutput string
+	templateExportFlagForce  bool
+)
+
+var templateExportCmd = &cobra.Command{
+	Use:          "export [flags]",
+	Short:        "Export built-in config template",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true, // 关闭错误时的帮助信息
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.Default()
+		if err != nil {
+			return err
+		}
+		output := templateExportFlagOutput
+		if output == "" {
+			output = conf.TemplatePath()
+		}
+		if output == "-" {
+			_, err := fmt.Fprint(os.Stdout, converter.DefaultTemplate())
+			return err
+		}
+		if _, err := os.Stat(output); err == nil && !templateExportFlagForce {
+			return fmt.Errorf("template '%s' already exists, use --force to overwrite", output)
+		}
+		if err := os.WriteFile(output, []byte(converter.DefaultTemplate()), 0660); err != nil {
+			return fmt.Errorf("export template to '%s' error:\n\t%w", output, err)
+		}
+		cmd.Printf("template exported to '%s'\n", output)
+		return nil
+	},
+}
+
+func init() {
+	templateExportCmd.Flags().StringVarP(&templateExportFlagOutput, "output", "o", "", "output path, '-' for stdout (default config home template)")
+	templateExportCmd.Flags().BoolVar(&templateExportFlagForce, "force", false, "overwrite existing template")
+
+	templateCmd.AddCommand(templateExportCmd)
+}
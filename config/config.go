@@ -9,6 +9,7 @@ import (
 type Config struct {
 	home              string
 	configPath        string
+	templatePath      string
 	singBoxBinaryPath string
 	singBoxConfigPath string
 	singBoxWorkingDir string
@@ -24,6 +25,7 @@ func New(home string) (*Config, error) {
 	return &Config{
 		home:              home,
 		configPath:        filepath.Join(home, "sing-box-ctl-config.json"),
+		templatePath:      filepath.Join(home, "config.json.tmpl"),
 		singBoxBinaryPath: filepath.Join(home, BinaryName),
 		singBoxConfigPath: filepath.Join(home, "config.json"),
 		singBoxWorkingDir: filepath.Join(home, "wd"),
@@ -43,6 +45,11 @@ func (c Config) ConfigPath() string {
 	return c.configPath
 }
 
+// 用户自定义的配置模板，存在时代替内置模板
+func (c Config) TemplatePath() string {
+	return c.templatePath
+}
+
 func (c Config) SingBoxBinaryPath() string {
 	return c.singBoxBinaryPath
 }
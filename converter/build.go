@@ -0,0 +1,243 @@
+package converter
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/follow1123/sing-box-ctl/singbox"
+)
+
+// 内置的 outbound 和 dns 服务器
+const (
+	tagAuto      = "自动选择"
+	tagOpenAi    = "OPENAI"
+	tagMicrosoft = "MICROSOFT"
+
+	dnsGoogleUdp = "dns-google-udp"
+	dnsGoogle    = "dns-google"
+	dnsAli       = "dns-ali"
+	dns114       = "dns-114"
+)
+
+// 生成 sing-box 配置，订阅中的节点、分组和规则插入到内置配置中
+func buildConfig(sbc *SingBoxConfig) *singbox.Config {
+	settings := cmp.Or(sbc.Settings, defaultSettings())
+	return &singbox.Config{
+		Log: &singbox.Log{
+			Level:     "info",
+			Output:    "box.log",
+			Timestamp: true,
+		},
+		Experimental: &singbox.Experimental{
+			ClashApi: &singbox.ClashApi{
+				ExternalController:       "127.0.0.1:9090",
+				ExternalUi:               "./ui/",
+				ExternalUiDownloadDetour: tagSelect,
+				DefaultMode:              "rule",
+			},
+			CacheFile: &singbox.CacheFile{Enabled: true},
+		},
+		DNS:       buildDns(sbc, settings),
+		Inbounds:  buildInbounds(settings),
+		Endpoints: sbc.Endpoints,
+		Outbounds: buildOutbounds(sbc),
+		Route:     buildRoute(sbc, settings),
+	}
+}
+
+func buildDns(sbc *SingBoxConfig, settings *Settings) *singbox.DNS {
+	rules := []singbox.DNSRule{
+		{ClashMode: "direct", Server: settings.LocalDns},
+		{ClashMode: "global", Server: settings.RemoteDns},
+	}
+	rules = append(rules, ruleDnsRules(sbc, settings)...)
+	rules = append(rules,
+		singbox.DNSRule{RuleSet: singbox.Listable[string]{"geosite-cn"}, Server: settings.LocalDns},
+		singbox.DNSRule{RuleSet: singbox.Listable[string]{"geosite-geolocation-!cn"}, Server: settings.RemoteDns},
+	)
+	return &singbox.DNS{
+		Servers:      settings.DNSServers,
+		Rules:        rules,
+		Strategy:     settings.Strategy,
+		Final:        settings.RemoteDns,
+		ClientSubnet: settings.ClientSubnet,
+	}
+}
+
+// 订阅中的 dns 规则和用户规则、订阅规则对应的 dns 规则，直连的规则使用国内的 dns
+func ruleDnsRules(sbc *SingBoxConfig, settings *Settings) []singbox.DNSRule {
+	rules := slices.Clone(settings.DNSRules)
+	for _, r := range slices.Concat(sbc.UserRules, sbc.Rules) {
+		if r.Action != "" {
+			continue
+		}
+		server := settings.RemoteDns
+		if r.Outbound == tagDirect {
+			server = settings.LocalDns
+		}
+		rules = append(rules, singbox.DNSRule{RuleSet: singbox.Listable[string]{r.RuleSet}, Server: server})
+	}
+	return rules
+}
+
+func buildInbounds(settings *Settings) []singbox.Inbound {
+	inbound := settings.Inbound
+	return []singbox.Inbound{{
+		Type:    "mixed",
+		Tag:     "mixed-in",
+		Options: &inbound,
+	}}
+}
+
+// 订阅生成的 outbound，依次为节点、relay 中间节点、订阅中的分组、合并订阅的分组和地区分组
+func generatedOutbounds(sbc *SingBoxConfig) []singbox.Outbound {
+	return slices.Concat(sbc.Outbounds, sbc.RelayHops, sbc.Groups, sbc.ProviderGroups, sbc.RegionGroups)
+}
+
+// 合并订阅和地区分组的 selector，selector 包含了对应的 urltest，只需要把 selector 添加到节点选择
+func selectorTags(sbc *SingBoxConfig) []string {
+	tags := make([]string, 0)
+	for _, g := range slices.Concat(sbc.ProviderGroups, sbc.RegionGroups) {
+		if g.Type == "selector" {
+			tags = append(tags, g.Tag)
+		}
+	}
+	return tags
+}
+
+// 订阅生成的 outbound 和内置分组
+func buildOutbounds(sbc *SingBoxConfig) []singbox.Outbound {
+	nodeTags := sbc.NodeTags()
+	selectTags := append([]string{tagAuto}, selectorTags(sbc)...)
+	return append(generatedOutbounds(sbc),
+		singbox.Outbound{
+			Type:    "selector",
+			Tag:     tagSelect,
+			Options: &singbox.Selector{Outbounds: append(selectTags, nodeTags...)},
+		},
+		singbox.Outbound{
+			Type:    "urltest",
+			Tag:     tagAuto,
+			Options: &singbox.UrlTest{Outbounds: nodeTags, Interval: "10m"},
+		},
+		singbox.Outbound{
+			Type:    "selector",
+			Tag:     tagOpenAi,
+			Options: &singbox.Selector{Outbounds: append(nodeFilter(nodeTags, "台湾"), tagAuto, tagSelect)},
+		},
+		singbox.Outbound{
+			Type: "selector",
+			Tag:  tagMicrosoft,
+			Options: &singbox.Selector{
+				Outbounds: []string{tagDirect, tagAuto, tagSelect},
+				Default:   tagDirect,
+			},
+		},
+		singbox.Outbound{Type: "direct", Tag: tagDirect},
+		singbox.Outbound{
+			Type: "selector",
+			Tag:  tagFinal,
+			Options: &singbox.Selector{
+				Outbounds: []string{tagSelect, tagDirect},
+				Default:   tagSelect,
+			},
+		},
+	)
+}
+
+// 包含 nodes 的 selector 分组和对应的 urltest 分组，selector 的第一个节点是 urltest
+func nodeGroups(tag string, autoTag string, nodes []string) []singbox.Outbound {
+	return []singbox.Outbound{
+		{
+			Type:    "selector",
+			Tag:     tag,
+			Options: &singbox.Selector{Outbounds: append([]string{autoTag}, nodes...)},
+		},
+		{
+			Type:    "urltest",
+			Tag:     autoTag,
+			Options: &singbox.UrlTest{Outbounds: nodes, Interval: "10m"},
+		},
+	}
+}
+
+func buildRoute(sbc *SingBoxConfig, settings *Settings) *singbox.Route {
+	rules := slices.Clone(settings.Sniff)
+	rules = append(rules,
+		singbox.Rule{
+			Type: "logical",
+			Mode: "or",
+			Rules: []singbox.Rule{
+				{Protocol: singbox.Listable[string]{"dns"}},
+				{Port: singbox.Listable[uint16]{53}},
+			},
+			Action: "hijack-dns",
+		},
+		singbox.Rule{IpIsPrivate: true, Outbound: tagDirect},
+		singbox.Rule{ClashMode: "direct", Outbound: tagDirect},
+		singbox.Rule{ClashMode: "global", Outbound: tagSelect},
+	)
+	// 用户自定义的规则优先于内置和订阅中的规则
+	rules = append(rules, toSingBoxRules(sbc.UserRules)...)
+	rules = append(rules,
+		singbox.Rule{RuleSet: singbox.Listable[string]{"geosite-openai"}, Outbound: tagOpenAi},
+		singbox.Rule{RuleSet: singbox.Listable[string]{"geosite-github"}, Outbound: tagSelect},
+		singbox.Rule{RuleSet: singbox.Listable[string]{"geosite-microsoft"}, Outbound: tagMicrosoft},
+	)
+	rules = append(rules, toSingBoxRules(sbc.Rules)...)
+	rules = append(rules,
+		singbox.Rule{RuleSet: singbox.Listable[string]{"geosite-cn", "geoip-cn"}, Outbound: tagDirect},
+		singbox.Rule{RuleSet: singbox.Listable[string]{"geosite-geolocation-!cn"}, Outbound: tagSelect},
+	)
+	if sbc.FinalRule != nil {
+		rules = append(rules, sbc.FinalRule.toSingBox())
+	}
+
+	ruleSets := generatedRuleSets(sbc)
+	for _, tag := range builtinRuleSets {
+		kind, code, _ := strings.Cut(tag, "-")
+		ruleSets = append(ruleSets, singbox.RuleSet{
+			Type:           "remote",
+			Tag:            tag,
+			Format:         "binary",
+			Url:            fmt.Sprintf(geoRuleSetUrl, kind, code),
+			DownloadDetour: tagSelect,
+		})
+	}
+	return &singbox.Route{
+		Rules:                 rules,
+		DefaultDomainResolver: settings.LocalDns,
+		AutoDetectInterface:   true,
+		Final:                 sbc.Final,
+		RuleSet:               ruleSets,
+	}
+}
+
+func toSingBoxRules(rules []Rule) []singbox.Rule {
+	result := make([]singbox.Rule, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, r.toSingBox())
+	}
+	return result
+}
+
+// 订阅中的规则和用户规则生成的规则集，不包括内置的规则集
+func generatedRuleSets(sbc *SingBoxConfig) []singbox.RuleSet {
+	ruleSets := make([]singbox.RuleSet, 0, len(sbc.InlineRuleSet)+len(sbc.RemoteRuleSet)+len(builtinRuleSets))
+	for _, rs := range sbc.InlineRuleSet {
+		ruleSets = append(ruleSets, rs.toSingBox())
+	}
+	for _, rs := range sbc.RemoteRuleSet {
+		ruleSets = append(ruleSets, singbox.RuleSet{
+			Type:           "remote",
+			Tag:            rs.Tag,
+			Format:         rs.Format,
+			Url:            rs.Url,
+			UpdateInterval: rs.UpdateInterval,
+			DownloadDetour: tagSelect,
+		})
+	}
+	return ruleSets
+}
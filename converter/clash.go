@@ -1,23 +1,222 @@
 package converter
 
+import "github.com/goccy/go-yaml"
+
 type ClashConfig struct {
-	Rules   []string `yaml:"rules"`
-	Proxies []Proxy  `yaml:"proxies"`
+	// 入站和 dns 设置，开启 WithClashSettings 时转换
+	MixedPort   int           `yaml:"mixed-port,omitempty"`
+	Port        int           `yaml:"port,omitempty"`
+	SocksPort   int           `yaml:"socks-port,omitempty"`
+	AllowLan    *bool         `yaml:"allow-lan,omitempty"`
+	BindAddress string        `yaml:"bind-address,omitempty"`
+	Hosts       yaml.MapSlice `yaml:"hosts,omitempty"`
+	Dns         *ClashDns     `yaml:"dns,omitempty"`
+	Sniffer     *Sniffer      `yaml:"sniffer,omitempty"`
+
+	Proxies       []Proxy                 `yaml:"proxies,omitempty"`
+	ProxyGroups   []ProxyGroup            `yaml:"proxy-groups,omitempty"`
+	RuleProviders map[string]RuleProvider `yaml:"rule-providers,omitempty"`
+	Rules         []string                `yaml:"rules,omitempty"`
 }
 
-type Proxy struct {
+// nameserver-policy 的 key 为域名或 geosite，value 为 dns 服务器或服务器列表
+type ClashDns struct {
+	Enable            bool          `yaml:"enable,omitempty"`
+	Ipv6              bool          `yaml:"ipv6,omitempty"`
+	EnhancedMode      string        `yaml:"enhanced-mode,omitempty"`
+	FakeIpRange       string        `yaml:"fake-ip-range,omitempty"`
+	FakeIpFilter      []string      `yaml:"fake-ip-filter,omitempty"`
+	DefaultNameserver []string      `yaml:"default-nameserver,omitempty"`
+	Nameserver        []string      `yaml:"nameserver,omitempty"`
+	Fallback          []string      `yaml:"fallback,omitempty"`
+	NameserverPolicy  yaml.MapSlice `yaml:"nameserver-policy,omitempty"`
+}
+
+// sniff 的 key 为 HTTP、TLS、QUIC
+type Sniffer struct {
+	Enable bool                  `yaml:"enable,omitempty"`
+	Sniff  map[string]SniffPorts `yaml:"sniff,omitempty"`
+}
+
+// 端口为数字或者 起始端口-结束端口 格式的范围
+type SniffPorts struct {
+	Ports []any `yaml:"ports,omitempty"`
+}
+
+type RuleProvider struct {
+	// http、file、inline
 	Type string `yaml:"type"`
+	// domain、ipcidr、classical
+	Behavior string `yaml:"behavior,omitempty"`
+	// yaml、text、mrs，默认为 yaml
+	Format   string   `yaml:"format,omitempty"`
+	Url      string   `yaml:"url,omitempty"`
+	Path     string   `yaml:"path,omitempty"`
+	Interval int      `yaml:"interval,omitempty"`
+	Payload  []string `yaml:"payload,omitempty"`
+}
+
+type ProxyGroup struct {
+	Name      string   `yaml:"name"`
+	Type      string   `yaml:"type"`
+	Proxies   []string `yaml:"proxies,omitempty"`
+	Url       string   `yaml:"url,omitempty"`
+	Interval  int      `yaml:"interval,omitempty"`
+	Tolerance int      `yaml:"tolerance,omitempty"`
+
+	// 以下属性只作用于 include-all 引入的节点
+	IncludeAll        bool   `yaml:"include-all,omitempty"`
+	IncludeAllProxies bool   `yaml:"include-all-proxies,omitempty"`
+	Filter            string `yaml:"filter,omitempty"`
+	ExcludeFilter     string `yaml:"exclude-filter,omitempty"`
+	ExcludeType       string `yaml:"exclude-type,omitempty"`
+}
+
+type Proxy struct {
 	// shadowsocks、trojan 协议通用属性
 	Name     string `yaml:"name"`
-	Server   string `yaml:"server"`
-	Port     int    `yaml:"port"`
-	Password string `yaml:"password"`
+	Type     string `yaml:"type"`
+	Server   string `yaml:"server,omitempty"`
+	Port     int    `yaml:"port,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	// 通过其他节点或分组连接服务器
+	DialerProxy string `yaml:"dialer-proxy,omitempty"`
 
 	// shadowsocks 协议属性
-	Cipher string `yaml:"cipher"`
-	Udp    bool   `yaml:"udp"`
+	Cipher     string      `yaml:"cipher,omitempty"`
+	Udp        bool        `yaml:"udp,omitempty"`
+	Plugin     string      `yaml:"plugin,omitempty"`
+	PluginOpts *PluginOpts `yaml:"plugin-opts,omitempty"`
 
 	// trojan 协议属性
-	Sni            string `yaml:"sni"`
-	SkipCertVerify bool   `yaml:"skip-cert-verify"`
+	Sni            string `yaml:"sni,omitempty"`
+	SkipCertVerify bool   `yaml:"skip-cert-verify,omitempty"`
+
+	// vmess 协议属性
+	Uuid       string `yaml:"uuid,omitempty"`
+	AlterId    int    `yaml:"alterId,omitempty"`
+	Tls        bool   `yaml:"tls,omitempty"`
+	ServerName string `yaml:"servername,omitempty"`
+
+	// vless 协议属性
+	Flow              string       `yaml:"flow,omitempty"`
+	PacketEncoding    string       `yaml:"packet-encoding,omitempty"`
+	ClientFingerprint string       `yaml:"client-fingerprint,omitempty"`
+	RealityOpts       *RealityOpts `yaml:"reality-opts,omitempty"`
+
+	// hysteria2 协议属性
+	Ports        string `yaml:"ports,omitempty"`
+	HopInterval  int    `yaml:"hop-interval,omitempty"`
+	Up           string `yaml:"up,omitempty"`
+	Down         string `yaml:"down,omitempty"`
+	Obfs         string `yaml:"obfs,omitempty"`
+	ObfsPassword string `yaml:"obfs-password,omitempty"`
+
+	// tuic 协议属性
+	CongestionController string `yaml:"congestion-controller,omitempty"`
+	UdpRelayMode         string `yaml:"udp-relay-mode,omitempty"`
+	ReduceRtt            bool   `yaml:"reduce-rtt,omitempty"`
+	HeartbeatInterval    int    `yaml:"heartbeat-interval,omitempty"`
+
+	// anytls 协议属性，单位为秒
+	IdleSessionCheckInterval int `yaml:"idle-session-check-interval,omitempty"`
+	IdleSessionTimeout       int `yaml:"idle-session-timeout,omitempty"`
+	MinIdleSession           int `yaml:"min-idle-session,omitempty"`
+
+	// ssh 协议属性，private-key 为私钥内容或者文件路径，和 wireguard 共用
+	PrivateKeyPassphrase string   `yaml:"private-key-passphrase,omitempty"`
+	HostKey              []string `yaml:"host-key,omitempty"`
+	HostKeyAlgorithms    []string `yaml:"host-key-algorithms,omitempty"`
+
+	// http、socks5 协议属性
+	Username string            `yaml:"username,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+
+	// wireguard 协议属性
+	Ip                  string              `yaml:"ip,omitempty"`
+	Ipv6                string              `yaml:"ipv6,omitempty"`
+	PrivateKey          string              `yaml:"private-key,omitempty"`
+	PublicKey           string              `yaml:"public-key,omitempty"`
+	PreSharedKey        string              `yaml:"pre-shared-key,omitempty"`
+	Reserved            any                 `yaml:"reserved,omitempty"`
+	AllowedIps          []string            `yaml:"allowed-ips,omitempty"`
+	Mtu                 int                 `yaml:"mtu,omitempty"`
+	PersistentKeepalive int                 `yaml:"persistent-keepalive,omitempty"`
+	Peers               []WireGuardPeerOpts `yaml:"peers,omitempty"`
+
+	// tls 属性，sni 和 servername 都是服务器名称，trojan、hysteria2、tuic、http 使用 sni
+	Alpn []string `yaml:"alpn,omitempty"`
+	// 服务器证书的 sha256 指纹
+	Fingerprint string   `yaml:"fingerprint,omitempty"`
+	Ca          string   `yaml:"ca,omitempty"`
+	CaStr       string   `yaml:"ca-str,omitempty"`
+	EchOpts     *EchOpts `yaml:"ech-opts,omitempty"`
+
+	// 传输层属性
+	Network  string    `yaml:"network,omitempty"`
+	WsOpts   *WsOpts   `yaml:"ws-opts,omitempty"`
+	GrpcOpts *GrpcOpts `yaml:"grpc-opts,omitempty"`
+	H2Opts   *H2Opts   `yaml:"h2-opts,omitempty"`
+	HttpOpts *HttpOpts `yaml:"http-opts,omitempty"`
+}
+
+// wireguard 节点，没有 peers 时使用节点本身的 server、public-key 等属性作为唯一的 peer
+type WireGuardPeerOpts struct {
+	Server       string   `yaml:"server,omitempty"`
+	Port         int      `yaml:"port,omitempty"`
+	PublicKey    string   `yaml:"public-key,omitempty"`
+	PreSharedKey string   `yaml:"pre-shared-key,omitempty"`
+	Reserved     any      `yaml:"reserved,omitempty"`
+	AllowedIps   []string `yaml:"allowed-ips,omitempty"`
+}
+
+// shadowsocks 插件参数，obfs 使用 mode、host，v2ray-plugin 使用 mode、host、path、tls、mux
+// shadow-tls 使用 host、password、version 和 tls 相关的参数
+type PluginOpts struct {
+	Mode           string            `yaml:"mode,omitempty"`
+	Host           string            `yaml:"host,omitempty"`
+	Path           string            `yaml:"path,omitempty"`
+	Tls            bool              `yaml:"tls,omitempty"`
+	Mux            bool              `yaml:"mux,omitempty"`
+	Headers        map[string]string `yaml:"headers,omitempty"`
+	Password       string            `yaml:"password,omitempty"`
+	Version        int               `yaml:"version,omitempty"`
+	SkipCertVerify bool              `yaml:"skip-cert-verify,omitempty"`
+	Fingerprint    string            `yaml:"fingerprint,omitempty"`
+	Alpn           []string          `yaml:"alpn,omitempty"`
+}
+
+type RealityOpts struct {
+	PublicKey string `yaml:"public-key,omitempty"`
+	ShortId   string `yaml:"short-id,omitempty"`
+}
+
+// config 为 base64 编码的 ECHConfigList，为空时通过 dns 查询
+type EchOpts struct {
+	Enable bool   `yaml:"enable,omitempty"`
+	Config string `yaml:"config,omitempty"`
+}
+
+type WsOpts struct {
+	Path                string            `yaml:"path,omitempty"`
+	Headers             map[string]string `yaml:"headers,omitempty"`
+	MaxEarlyData        int               `yaml:"max-early-data,omitempty"`
+	EarlyDataHeaderName string            `yaml:"early-data-header-name,omitempty"`
+	V2rayHttpUpgrade    bool              `yaml:"v2ray-http-upgrade,omitempty"`
+}
+
+type GrpcOpts struct {
+	GrpcServiceName string `yaml:"grpc-service-name,omitempty"`
+}
+
+type H2Opts struct {
+	Host []string `yaml:"host,omitempty"`
+	Path string   `yaml:"path,omitempty"`
+}
+
+type HttpOpts struct {
+	Method  string              `yaml:"method,omitempty"`
+	Path    []string            `yaml:"path,omitempty"`
+	Headers map[string][]string `yaml:"headers,omitempty"`
 }
@@ -2,70 +2,208 @@ package converter
 
 import (
 	"bytes"
-	_ "embed"
+	"cmp"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"log"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/follow1123/sing-box-ctl/singbox"
 	"github.com/goccy/go-yaml"
 )
 
-//go:embed config.json.tmpl
-var singBoxConfigTemplate string
+// 模板内置的 outbound
+const (
+	tagDirect = "直连"
+	tagSelect = "节点选择"
+	tagFinal  = "漏网之鱼"
+)
 
-func Convert(data []byte) ([]byte, error) {
-	cc := &ClashConfig{}
-	if err := yaml.Unmarshal(data, cc); err != nil {
-		return nil, fmt.Errorf("unmarshal clash config error: \n\t%w", err)
+type options struct {
+	loader     func(source string) ([]byte, error)
+	template   string
+	nodeFilter NodeFilter
+	regions    []Region
+	userRules  []UserRule
+	// 使用 clash 配置中的 dns、hosts、入站和嗅探设置
+	clashSettings bool
+
+	nodes *nodeMatcher
+}
+
+type Option func(*options)
+
+// 设置加载 file、http 类型 rule-provider 内容的方法，未设置时这类 rule-provider 只能转换为远程规则集
+func WithLoader(loader func(source string) ([]byte, error)) Option {
+	return func(o *options) {
+		o.loader = loader
 	}
-	sbc := clashToSingBox(cc)
+}
 
-	tmpl := template.New("sing-box-config-tmpl").Funcs(template.FuncMap{
-		"nodeFilter": nodeFilter,
-	})
-	tmpl, err := tmpl.Parse(singBoxConfigTemplate)
+// 设置生成配置使用的模板，未设置时直接输出生成的配置
+func WithTemplate(text string) Option {
+	return func(o *options) {
+		o.template = text
+	}
+}
+
+// 设置节点的过滤和重命名规则，在生成 outbound 之前处理
+func WithNodeFilter(filter NodeFilter) Option {
+	return func(o *options) {
+		o.nodeFilter = filter
+	}
+}
+
+// 设置生成地区分组使用的地区列表，未设置时使用内置的地区列表，为空时不生成地区分组
+func WithRegions(regions []Region) Option {
+	return func(o *options) {
+		o.regions = regions
+	}
+}
+
+// 设置用户自定义的规则，插入到内置规则和订阅中的规则之前
+func WithUserRules(rules []UserRule) Option {
+	return func(o *options) {
+		o.userRules = rules
+	}
+}
+
+// 设置是否使用 clash 配置中的 dns、hosts、端口、allow-lan 和 sniffer 代替内置的设置，订阅中没有的设置仍然使用内置的设置
+func WithClashSettings(enabled bool) Option {
+	return func(o *options) {
+		o.clashSettings = enabled
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{regions: defaultRegions}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// 转换订阅内容，同时返回转换报告
+func Convert(data []byte, opts ...Option) ([]byte, *Report, error) {
+	o := newOptions(opts)
+	nodes, err := o.nodeFilter.compile()
+	if err != nil {
+		return nil, nil, fmt.Errorf("compile node filter error: \n\t%w", err)
+	}
+	o.nodes = nodes
+	sbc, err := toSingBox(data, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return generate(sbc, o)
+}
+
+// 生成地区分组和用户规则后输出最终的配置
+func generate(sbc *SingBoxConfig, o *options) ([]byte, *Report, error) {
+	var err error
+	sbc.RegionGroups, err = buildRegionGroups(sbc, o.regions)
+	if err != nil {
+		return nil, nil, err
+	}
+	convertUserRules(o.userRules, sbc)
+	sbc.report.addRuleSets(sbc)
+
+	config := buildConfig(sbc)
+	if err := checkDetourCycle(config); err != nil {
+		return nil, nil, err
+	}
+	if o.template == "" {
+		result, err := config.MarshalIndent()
+		if err != nil {
+			return nil, nil, err
+		}
+		return result, sbc.report, nil
+	}
+
+	tmpl, err := template.New("sing-box-config-tmpl").Funcs(templateFuncs).Parse(o.template)
 	if err != nil {
-		return nil, fmt.Errorf("load tempalte error: \n\t%w", err)
+		return nil, nil, fmt.Errorf("load tempalte error: \n\t%w", err)
 	}
 
+	var data any = newTemplateData(sbc, config)
+	if isLegacyTemplate(tmpl) {
+		if data, err = newLegacyTemplateData(sbc); err != nil {
+			return nil, nil, fmt.Errorf("load tempalte error: \n\t%w", err)
+		}
+	}
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, sbc); err != nil {
-		return nil, fmt.Errorf("execute tempalte error: \n\t%w", err)
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, nil, fmt.Errorf("execute tempalte error: \n\t%w", err)
 	}
 
-	return buf.Bytes(), nil
+	return buf.Bytes(), sbc.report, nil
 }
 
-func nodeFilter(outbounds []Outbound, keys string) []string {
-	keyArr := strings.Split(keys, "|")
-	result := make([]string, 0)
-	for _, outbound := range outbounds {
-		for _, k := range keyArr {
-			if strings.Contains(outbound.Tag, k) {
-				result = append(result, outbound.Tag)
-				break
-			}
+// 识别订阅的格式并转换，支持 sing-box 配置、SIP008、分享链接和 clash 配置
+func toSingBox(data []byte, o *options) (*SingBoxConfig, error) {
+	if jh, ok := decodeNative(data); ok {
+		sbc, err := nativeToSingBox(jh, o.nodes)
+		if err != nil {
+			return nil, fmt.Errorf("convert sing-box config error: \n\t%w", err)
 		}
+		return sbc, nil
+	}
+	sbc := newSingBoxConfig()
+	if sc, ok := decodeSip008(data); ok {
+		clashToSingBox(sip008ToClash(sc, sbc.report), sbc, o)
+		if len(sbc.Outbounds) == 0 {
+			return nil, fmt.Errorf("convert sip008 config error: \n\t%w", errors.New("no supported servers"))
+		}
+		return sbc, nil
+	}
+	if links, ok := decodeShareLinks(data); ok {
+		clashToSingBox(shareLinksToClash(links, sbc.report), sbc, o)
+		return sbc, nil
 	}
-	return result
+	cc := &ClashConfig{}
+	if err := yaml.Unmarshal(data, cc); err != nil {
+		return nil, fmt.Errorf("unmarshal clash config error: \n\t%w", err)
+	}
+	clashToSingBox(cc, sbc, o)
+	return sbc, nil
 }
 
-func clashToSingBox(cc *ClashConfig) *SingBoxConfig {
-	sbc := &SingBoxConfig{
-		Outbounds:     make([]Outbound, 0),
+func newSingBoxConfig() *SingBoxConfig {
+	report := newReport()
+	return &SingBoxConfig{
+		Outbounds:     make([]singbox.Outbound, 0),
+		Endpoints:     make([]singbox.Endpoint, 0),
+		Groups:        make([]singbox.Outbound, 0),
 		Rules:         make([]Rule, 0),
 		InlineRuleSet: make([]InlineRuleSet, 0),
+		RemoteRuleSet: make([]RemoteRuleSet, 0),
+		Final:         tagFinal,
+		report:        report,
+		tags:          newTagAllocator(report),
 	}
+}
+
+func clashToSingBox(cc *ClashConfig, sbc *SingBoxConfig, o *options) {
+	filterProxies(cc, o.nodes, sbc.tags)
+	uniqueGroupNames(cc, sbc.tags)
 	convertProxies(cc, sbc)
-	convertRules(cc, sbc)
-	return sbc
+	convertRelayGroups(cc, sbc)
+	checkDialerProxies(cc, sbc)
+	convertProxyGroups(cc, sbc)
+	convertRules(cc, sbc, o)
+	if o.clashSettings {
+		convertSettings(cc, sbc)
+	}
 }
 
 // 转换协议
 func convertProxies(cc *ClashConfig, sbc *SingBoxConfig) {
 	for _, p := range cc.Proxies {
-		var ob Outbound
+		var ob singbox.Outbound
 
 		network := "tcp"
 		if p.Udp {
@@ -73,99 +211,730 @@ func convertProxies(cc *ClashConfig, sbc *SingBoxConfig) {
 		}
 		switch p.Type {
 		case "ss":
-			ob = Outbound{
-				Type: "shadowsocks",
-				Tag:  p.Name,
-				Protocol: Shadowsocks{
-					Server:     p.Server,
-					ServerPort: p.Port,
-					Method:     p.Cipher,
-					Password:   p.Password,
-					Network:    network,
-				},
+			ss := &singbox.Shadowsocks{
+				ServerOptions: serverOptions(p),
+				Method:        p.Cipher,
+				Password:      p.Password,
+				Network:       network,
+			}
+			if p.Plugin == "shadow-tls" {
+				shadowTls, err := convertShadowTls(p)
+				if err != nil {
+					sbc.report.skipProxy(p.Name, err)
+					continue
+				}
+				// shadow-tls 作为中间节点连接服务器，只支持 tcp
+				shadowTls.Tag = sbc.tags.unique(shadowTls.Tag)
+				sbc.RelayHops = append(sbc.RelayHops, shadowTls)
+				ss.Detour = shadowTls.Tag
+				ss.Network = "tcp"
+			} else {
+				plugin, pluginOpts, err := convertPlugin(p)
+				if err != nil {
+					sbc.report.skipProxy(p.Name, err)
+					continue
+				}
+				ss.Plugin = plugin
+				ss.PluginOpts = pluginOpts
+			}
+			ob = singbox.Outbound{
+				Type:    "shadowsocks",
+				Tag:     p.Name,
+				Options: ss,
 			}
 		case "trojan":
-			ob = Outbound{
+			transport, err := convertTransport(p)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			// trojan 总是使用 tls
+			tls, err := convertTls(p, true)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			ob = singbox.Outbound{
 				Type: "trojan",
 				Tag:  p.Name,
-				Protocol: Trojan{
-					Server:     p.Server,
-					ServerPort: p.Port,
-					Password:   p.Password,
-					Network:    "tcp",
-					Tls: Tls{
-						Enabled:    !p.SkipCertVerify,
-						ServerName: p.Sni,
-					},
+				Options: &singbox.Trojan{
+					ServerOptions: serverOptions(p),
+					Password:      p.Password,
+					Network:       "tcp",
+					Tls:           tls,
+					Transport:     transport,
 				},
 			}
+		case "vmess":
+			transport, err := convertTransport(p)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			tls, err := convertTls(p, false)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			security := p.Cipher
+			if security == "" {
+				security = "auto"
+			}
+			vmess := &singbox.Vmess{
+				ServerOptions: serverOptions(p),
+				Uuid:          p.Uuid,
+				Security:      security,
+				AlterId:       p.AlterId,
+				Tls:           tls,
+				Transport:     transport,
+			}
+			if !p.Udp {
+				vmess.Network = "tcp"
+			}
+			ob = singbox.Outbound{
+				Type:    "vmess",
+				Tag:     p.Name,
+				Options: vmess,
+			}
+		case "vless":
+			transport, err := convertTransport(p)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			tls, err := convertTls(p, false)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			vless := &singbox.Vless{
+				ServerOptions:  serverOptions(p),
+				Uuid:           p.Uuid,
+				Flow:           p.Flow,
+				PacketEncoding: p.PacketEncoding,
+				Tls:            tls,
+				Transport:      transport,
+			}
+			if !p.Udp {
+				vless.Network = "tcp"
+			}
+			ob = singbox.Outbound{
+				Type:    "vless",
+				Tag:     p.Name,
+				Options: vless,
+			}
+		case "hysteria2":
+			// quic 协议不支持 utls 和 reality
+			tls, err := tlsOptions(p)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			hy2 := &singbox.Hysteria2{
+				ServerOptions: serverOptions(p),
+				Password:      p.Password,
+				Tls:           tls,
+			}
+			if p.Ports != "" {
+				ports, err := convertPorts(p.Ports)
+				if err != nil {
+					sbc.report.skipProxy(p.Name, err)
+					continue
+				}
+				hy2.ServerPorts = ports
+				if p.HopInterval > 0 {
+					hy2.HopInterval = fmt.Sprintf("%ds", p.HopInterval)
+				}
+			}
+			up, err := convertBandwidth(p.Up)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			down, err := convertBandwidth(p.Down)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			hy2.UpMbps = up
+			hy2.DownMbps = down
+			if p.Obfs != "" {
+				hy2.Obfs = &singbox.Obfs{
+					Type:     p.Obfs,
+					Password: p.ObfsPassword,
+				}
+			}
+			ob = singbox.Outbound{
+				Type:    "hysteria2",
+				Tag:     p.Name,
+				Options: hy2,
+			}
+		case "tuic":
+			tls, err := tlsOptions(p)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			tuic := &singbox.Tuic{
+				ServerOptions:     serverOptions(p),
+				Uuid:              p.Uuid,
+				Password:          p.Password,
+				CongestionControl: p.CongestionController,
+				UdpRelayMode:      p.UdpRelayMode,
+				ZeroRttHandshake:  p.ReduceRtt,
+				Tls:               tls,
+			}
+			if p.HeartbeatInterval > 0 {
+				tuic.Heartbeat = fmt.Sprintf("%dms", p.HeartbeatInterval)
+			}
+			ob = singbox.Outbound{
+				Type:    "tuic",
+				Tag:     p.Name,
+				Options: tuic,
+			}
+		case "anytls":
+			// anytls 总是使用 tls
+			tls, err := convertTls(p, true)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			anyTls := &singbox.AnyTls{
+				ServerOptions:  serverOptions(p),
+				Password:       p.Password,
+				MinIdleSession: p.MinIdleSession,
+				Tls:            tls,
+			}
+			if p.IdleSessionCheckInterval > 0 {
+				anyTls.IdleSessionCheckInterval = fmt.Sprintf("%ds", p.IdleSessionCheckInterval)
+			}
+			if p.IdleSessionTimeout > 0 {
+				anyTls.IdleSessionTimeout = fmt.Sprintf("%ds", p.IdleSessionTimeout)
+			}
+			ob = singbox.Outbound{
+				Type:    "anytls",
+				Tag:     p.Name,
+				Options: anyTls,
+			}
+		case "http":
+			tls, err := convertTls(p, false)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			http := &singbox.Http{
+				ServerOptions: serverOptions(p),
+				Username:      p.Username,
+				Password:      p.Password,
+				Tls:           tls,
+			}
+			for name, value := range p.Headers {
+				if http.Headers == nil {
+					http.Headers = make(singbox.HttpHeader)
+				}
+				http.Headers[name] = singbox.Listable[string]{value}
+			}
+			ob = singbox.Outbound{
+				Type:    "http",
+				Tag:     p.Name,
+				Options: http,
+			}
+		case "socks5":
+			// sing-box 的 socks outbound 不支持 tls
+			if p.Tls {
+				sbc.report.skipProxy(p.Name, errors.New("socks5 over tls is not supported"))
+				continue
+			}
+			socks := &singbox.Socks{
+				ServerOptions: serverOptions(p),
+				Version:       "5",
+				Username:      p.Username,
+				Password:      p.Password,
+			}
+			if !p.Udp {
+				socks.Network = "tcp"
+			}
+			ob = singbox.Outbound{
+				Type:    "socks",
+				Tag:     p.Name,
+				Options: socks,
+			}
+		case "ssh":
+			ssh, err := convertSsh(p)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			ob = singbox.Outbound{
+				Type:    "ssh",
+				Tag:     p.Name,
+				Options: ssh,
+			}
+		case "wireguard":
+			wg, err := convertWireGuard(p)
+			if err != nil {
+				sbc.report.skipProxy(p.Name, err)
+				continue
+			}
+			// wireguard 是 endpoint 不是 outbound
+			sbc.Endpoints = append(sbc.Endpoints, singbox.Endpoint{
+				Type:    "wireguard",
+				Tag:     p.Name,
+				Options: wg,
+			})
+			sbc.report.addProxy(p.Name)
+			continue
 		default:
-			log.Printf("unsupport protocol: %v\n", p.Type)
+			sbc.report.skipProxy(p.Name, fmt.Errorf("unsupport protocol '%s'", p.Type))
 			continue
 		}
 		sbc.Outbounds = append(sbc.Outbounds, ob)
+		sbc.report.addProxy(p.Name)
+	}
+}
+
+func serverOptions(p Proxy) singbox.ServerOptions {
+	return singbox.ServerOptions{
+		Server:     p.Server,
+		ServerPort: p.Port,
+		Detour:     dialerProxy(p),
+	}
+}
+
+// 转换 tls 配置，required 为 false 时只在开启 tls 或者有 reality-opts 时转换，否则返回 nil
+func convertTls(p Proxy, required bool) (*singbox.Tls, error) {
+	// reality 必须配合 tls 使用，有 reality-opts 时默认开启
+	if !required && !p.Tls && p.RealityOpts == nil {
+		return nil, nil
+	}
+	tls, err := tlsOptions(p)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := p.ClientFingerprint
+	if p.RealityOpts != nil {
+		tls.Reality = &singbox.Reality{
+			Enabled:   true,
+			PublicKey: p.RealityOpts.PublicKey,
+			ShortId:   p.RealityOpts.ShortId,
+		}
+		// sing-box 的 reality 依赖 utls
+		if fingerprint == "" {
+			fingerprint = "chrome"
+		}
+	}
+	if fingerprint != "" {
+		tls.Utls = &singbox.Utls{Enabled: true, Fingerprint: fingerprint}
+	}
+	return tls, nil
+}
+
+// 设置了证书指纹的节点和 shadow-tls 插件都跳过
+var errTlsFingerprint = errors.New("tls certificate fingerprint is not supported")
+
+// 所有协议通用的 tls 配置
+func tlsOptions(p Proxy) (*singbox.Tls, error) {
+	// sing-box 只能通过证书固定服务器证书，不支持证书指纹，忽略后不再校验证书，所以跳过这个节点
+	if p.Fingerprint != "" {
+		return nil, errTlsFingerprint
+	}
+	tls := &singbox.Tls{
+		Enabled:         true,
+		ServerName:      cmp.Or(p.Sni, p.ServerName),
+		Insecure:        p.SkipCertVerify,
+		Alpn:            p.Alpn,
+		Certificate:     pemLines(p.CaStr),
+		CertificatePath: p.Ca,
+	}
+	if opts := p.EchOpts; opts != nil && opts.Enable {
+		tls.Ech = &singbox.Ech{Enabled: true}
+		if opts.Config != "" {
+			if _, err := base64.StdEncoding.DecodeString(opts.Config); err != nil {
+				return nil, fmt.Errorf("invalid ech config '%s'", opts.Config)
+			}
+			tls.Ech.Config = []string{"-----BEGIN ECH CONFIGS-----", opts.Config, "-----END ECH CONFIGS-----"}
+		}
+	}
+	return tls, nil
+}
+
+// PEM 格式的内容按行分割
+func pemLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// private-key 是 PEM 格式的私钥时作为私钥内容，否则作为私钥文件的路径
+func convertSsh(p Proxy) (*singbox.Ssh, error) {
+	if p.Password == "" && p.PrivateKey == "" {
+		return nil, errors.New("no password or private key")
+	}
+	// host-key 的格式为 算法 公钥，例如 ssh-ed25519 AAAA...
+	for _, key := range p.HostKey {
+		if len(strings.Fields(key)) < 2 {
+			return nil, fmt.Errorf("invalid host key '%s'", key)
+		}
+	}
+	ssh := &singbox.Ssh{
+		ServerOptions:        serverOptions(p),
+		User:                 p.Username,
+		Password:             p.Password,
+		PrivateKeyPassphrase: p.PrivateKeyPassphrase,
+		HostKey:              p.HostKey,
+		HostKeyAlgorithms:    p.HostKeyAlgorithms,
+	}
+	if strings.Contains(p.PrivateKey, "-----BEGIN") {
+		ssh.PrivateKey = pemLines(p.PrivateKey)
+	} else {
+		ssh.PrivateKeyPath = p.PrivateKey
+	}
+	return ssh, nil
+}
+
+func convertWireGuard(p Proxy) (*singbox.WireGuard, error) {
+	wg := &singbox.WireGuard{
+		Mtu:        p.Mtu,
+		PrivateKey: p.PrivateKey,
+		Detour:     dialerProxy(p),
 	}
+	if p.Ip != "" {
+		wg.Address = append(wg.Address, withPrefix(p.Ip, "/32"))
+	}
+	if p.Ipv6 != "" {
+		wg.Address = append(wg.Address, withPrefix(p.Ipv6, "/128"))
+	}
+	if len(wg.Address) == 0 {
+		return nil, errors.New("no local address")
+	}
+	peers := p.Peers
+	if len(peers) == 0 {
+		peers = []WireGuardPeerOpts{{
+			Server:       p.Server,
+			Port:         p.Port,
+			PublicKey:    p.PublicKey,
+			PreSharedKey: p.PreSharedKey,
+			Reserved:     p.Reserved,
+			AllowedIps:   p.AllowedIps,
+		}}
+	}
+	for _, peer := range peers {
+		reserved, err := convertReserved(peer.Reserved)
+		if err != nil {
+			return nil, err
+		}
+		allowedIps := peer.AllowedIps
+		if len(allowedIps) == 0 {
+			allowedIps = []string{"0.0.0.0/0", "::/0"}
+		}
+		wg.Peers = append(wg.Peers, singbox.WireGuardPeer{
+			Address:                     peer.Server,
+			Port:                        peer.Port,
+			PublicKey:                   peer.PublicKey,
+			PreSharedKey:                peer.PreSharedKey,
+			AllowedIps:                  allowedIps,
+			PersistentKeepaliveInterval: p.PersistentKeepalive,
+			Reserved:                    reserved,
+		})
+	}
+	return wg, nil
 }
 
-// 转换规则
-func convertRules(cc *ClashConfig, sbc *SingBoxConfig) {
-	currentOutbound := ""
-	ruleIdx := -1
-	for i, r := range cc.Rules {
-		items := strings.Split(r, ",")
+// 地址没有前缀长度时补充前缀
+func withPrefix(addr string, prefix string) string {
+	if strings.Contains(addr, "/") {
+		return addr
+	}
+	return addr + prefix
+}
 
-		if len(items) < 3 {
-			log.Printf("ignore rule：%v\n", r)
+// 转换 wireguard reserved，clash 格式为 [1, 2, 3] 或 base64 字符串
+func convertReserved(reserved any) ([]int, error) {
+	switch r := reserved.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if r == "" {
+			return nil, nil
+		}
+		data, err := base64.StdEncoding.DecodeString(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reserved '%s'", r)
+		}
+		result := make([]int, 0, len(data))
+		for _, b := range data {
+			result = append(result, int(b))
+		}
+		return result, nil
+	case []any:
+		result := make([]int, 0, len(r))
+		for _, item := range r {
+			value, err := strconv.Atoi(fmt.Sprint(item))
+			if err != nil || value < 0 || value > 255 {
+				return nil, fmt.Errorf("invalid reserved '%v'", reserved)
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("invalid reserved '%v'", reserved)
+	}
+}
+
+// 转换端口跳跃范围，clash 格式为 443,1000-2000，sing-box 格式为 ["443:443", "1000:2000"]
+func convertPorts(ports string) ([]string, error) {
+	var result []string
+	for _, item := range strings.Split(ports, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
 			continue
 		}
+		start, end, found := strings.Cut(item, "-")
+		if !found {
+			end = start
+		}
+		if _, err := strconv.ParseUint(start, 10, 16); err != nil {
+			return nil, fmt.Errorf("invalid ports '%s'", ports)
+		}
+		if _, err := strconv.ParseUint(end, 10, 16); err != nil {
+			return nil, fmt.Errorf("invalid ports '%s'", ports)
+		}
+		result = append(result, start+":"+end)
+	}
+	return result, nil
+}
+
+// 转换带宽为 Mbps，clash 格式为 100、100 Mbps、1 Gbps 等，不带单位时默认为 Mbps
+func convertBandwidth(bandwidth string) (int, error) {
+	bandwidth = strings.TrimSpace(bandwidth)
+	if bandwidth == "" {
+		return 0, nil
+	}
+	idx := strings.IndexFunc(bandwidth, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	num, unit := bandwidth, ""
+	if idx >= 0 {
+		num, unit = bandwidth[:idx], strings.TrimSpace(bandwidth[idx:])
+	}
+	value, err := strconv.Atoi(num)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth '%s'", bandwidth)
+	}
+	switch strings.ToLower(unit) {
+	case "", "m", "mbps":
+		return value, nil
+	case "g", "gbps":
+		return value * 1000, nil
+	case "k", "kbps":
+		return max(value/1000, 1), nil
+	default:
+		return 0, fmt.Errorf("invalid bandwidth unit '%s'", unit)
+	}
+}
+
+// 转换传输层，tcp 或未指定时返回 nil
+func convertTransport(p Proxy) (*singbox.Transport, error) {
+	switch p.Network {
+	case "", "tcp":
+		return nil, nil
+	case "ws":
+		t := &singbox.Transport{Type: "ws"}
+		if opts := p.WsOpts; opts != nil {
+			if opts.V2rayHttpUpgrade {
+				t.Type = "httpupgrade"
+			} else {
+				t.MaxEarlyData = opts.MaxEarlyData
+				t.EarlyDataHeaderName = opts.EarlyDataHeaderName
+			}
+			t.Path = opts.Path
+			for name, value := range opts.Headers {
+				// httpupgrade 的 Host 是单独的字段
+				if t.Type == "httpupgrade" && strings.EqualFold(name, "host") {
+					t.Host = singbox.Listable[string]{value}
+					continue
+				}
+				if t.Headers == nil {
+					t.Headers = make(singbox.HttpHeader)
+				}
+				t.Headers[name] = singbox.Listable[string]{value}
+			}
+		}
+		return t, nil
+	case "h2":
+		t := &singbox.Transport{Type: "http"}
+		if opts := p.H2Opts; opts != nil {
+			t.Host = opts.Host
+			t.Path = opts.Path
+		}
+		return t, nil
+	case "http":
+		t := &singbox.Transport{Type: "http"}
+		if opts := p.HttpOpts; opts != nil {
+			t.Method = opts.Method
+			if len(opts.Path) > 0 {
+				t.Path = opts.Path[0]
+			}
+			for name, value := range opts.Headers {
+				if strings.EqualFold(name, "host") {
+					t.Host = value
+					continue
+				}
+				if t.Headers == nil {
+					t.Headers = make(singbox.HttpHeader)
+				}
+				t.Headers[name] = value
+			}
+		}
+		return t, nil
+	case "grpc":
+		t := &singbox.Transport{Type: "grpc"}
+		if opts := p.GrpcOpts; opts != nil {
+			t.ServiceName = opts.GrpcServiceName
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("unsupport network '%s'", p.Network)
+	}
+}
 
-		name := ruleType(items[0])
-		if name == "" {
-			log.Printf("unsupport condition name: %v\n", items[0])
+// 转换分组，select 转换为 selector，url-test、fallback、load-balance 转换为 urltest
+func convertProxyGroups(cc *ClashConfig, sbc *SingBoxConfig) {
+	groupTypes := make(map[string]string)
+	// include-all 筛选出的节点，筛选条件错误的分组直接跳过，避免其他分组引用
+	groupNodes := make(map[string][]string)
+	nodeTags := sbc.NodeTags()
+	for _, g := range cc.ProxyGroups {
+		var groupType string
+		switch g.Type {
+		case "select":
+			groupType = "selector"
+		case "url-test", "fallback", "load-balance":
+			groupType = "urltest"
+		case "relay":
+			// relay 分组已经转换为节点
+			continue
+		default:
+			sbc.report.skipGroup(g.Name, fmt.Errorf("unsupport proxy group type '%s'", g.Type))
 			continue
 		}
-		value := items[1]
-		outbound := items[2]
-		if currentOutbound != outbound || i == len(cc.Rules)-1 {
-			currentOutbound = outbound
-			ruleIdx++
-			ruleSetName := fmt.Sprintf("providers-builtin-rule-%v", ruleIdx+1)
-			if strings.Contains(outbound, "直连") || strings.Contains(strings.ToLower(outbound), "direct") {
-				outbound = "直连"
-			} else if strings.Contains(outbound, "选择") || strings.Contains(strings.ToLower(outbound), "select") {
-				outbound = "节点选择"
-			}
-			sbc.Rules = append(sbc.Rules, Rule{
-				RuleSet:  ruleSetName,
-				Outbound: outbound,
-			})
-			headlessRule := &HeadlessRule{
-				Conditions: make([]RuleCondition, 0),
+		if g.IncludeAll || g.IncludeAllProxies {
+			nodes, err := filterNodes(cc, nodeTags, g)
+			if err != nil {
+				sbc.report.skipGroup(g.Name, err)
+				continue
 			}
-			headlessRule.AddCondition(name, value)
-			sbc.InlineRuleSet = append(sbc.InlineRuleSet, InlineRuleSet{
-				Tag:          ruleSetName,
-				HeadlessRule: headlessRule,
-			})
+			groupNodes[g.Name] = nodes
+		}
+		groupTypes[g.Name] = groupType
+	}
+	for _, g := range cc.ProxyGroups {
+		groupType, ok := groupTypes[g.Name]
+		if !ok {
+			continue
+		}
+		members := make([]string, 0)
+		seen := make(map[string]bool)
+		add := func(name string) {
+			if !seen[name] {
+				seen[name] = true
+				members = append(members, name)
+			}
+		}
+		for _, name := range g.Proxies {
+			switch name {
+			case "DIRECT":
+				add(tagDirect)
+			case "REJECT", "REJECT-DROP", "PASS", "COMPATIBLE":
+				continue
+			default:
+				_, isGroup := groupTypes[name]
+				if isGroup || slices.Contains(nodeTags, name) {
+					add(name)
+				}
+			}
+		}
+		for _, node := range groupNodes[g.Name] {
+			add(node)
+		}
+		// sing-box 不允许空分组
+		if len(members) == 0 {
+			members = append(members, tagDirect)
+		}
+		group := singbox.Outbound{
+			Type:    groupType,
+			Tag:     g.Name,
+			Options: &singbox.Selector{Outbounds: members},
+		}
+		if groupType == "urltest" {
+			urlTest := &singbox.UrlTest{
+				Outbounds: members,
+				Url:       g.Url,
+				Tolerance: g.Tolerance,
+			}
+			if g.Interval > 0 {
+				urlTest.Interval = fmt.Sprintf("%ds", g.Interval)
+			}
+			group.Options = urlTest
+		}
+		sbc.Groups = append(sbc.Groups, group)
+	}
+}
+
+// 根据 filter、exclude-filter、exclude-type 筛选所有节点，多个正则使用 ` 分隔
+func filterNodes(cc *ClashConfig, nodeTags []string, g ProxyGroup) ([]string, error) {
+	filters, err := compilePatterns(g.Filter)
+	if err != nil {
+		return nil, err
+	}
+	excludeFilters, err := compilePatterns(g.ExcludeFilter)
+	if err != nil {
+		return nil, err
+	}
+	var excludeTypes []string
+	if g.ExcludeType != "" {
+		excludeTypes = strings.Split(strings.ToLower(g.ExcludeType), "|")
+	}
+	nodeTypes := make(map[string]string)
+	for _, p := range cc.Proxies {
+		nodeTypes[p.Name] = strings.ToLower(p.Type)
+	}
+	matchAny := func(patterns []*regexp.Regexp, tag string) bool {
+		return slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool {
+			return re.MatchString(tag)
+		})
+	}
+	result := make([]string, 0)
+	for _, tag := range nodeTags {
+		if len(filters) > 0 && !matchAny(filters, tag) {
 			continue
 		}
-		sbc.InlineRuleSet[ruleIdx].HeadlessRule.AddCondition(name, value)
+		if matchAny(excludeFilters, tag) {
+			continue
+		}
+		if slices.Contains(excludeTypes, nodeTypes[tag]) {
+			continue
+		}
+		result = append(result, tag)
 	}
+	return result, nil
 }
 
-func ruleType(clashRule string) string {
-	switch clashRule {
-	case "DOMAIN":
-		return "domain"
-	case "DOMAIN-SUFFIX":
-		return "domain_suffix"
-	case "DOMAIN-KEYWORD":
-		return "domain_keyword"
-	case "IP-CIDR", "IP-CIDR6":
-		return "ip_cidr"
-	case "PROCESS-NAME":
-		return "process_name"
-	default:
-		return ""
+func compilePatterns(patterns string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	if patterns == "" {
+		return result, nil
+	}
+	for _, pattern := range strings.Split(patterns, "`") {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter '%s'", pattern)
+		}
+		result = append(result, re)
 	}
+	return result, nil
 }
@@ -0,0 +1,220 @@
+package converter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	JH "github.com/follow1123/sing-box-ctl/jsonhandler"
+	"github.com/follow1123/sing-box-ctl/singbox"
+	"github.com/tidwall/gjson"
+)
+
+// dialer-proxy 为 DIRECT 时直接连接
+func dialerProxy(p Proxy) string {
+	if p.DialerProxy == "DIRECT" {
+		return ""
+	}
+	return p.DialerProxy
+}
+
+// 转换 relay 分组，proxies 为 [a, b, c] 时依次通过 a、b 连接 c
+// 除第一个外的节点复制一份并将 detour 设置为上一个节点，最后一个节点使用分组的名称作为 tag
+func convertRelayGroups(cc *ClashConfig, sbc *SingBoxConfig) {
+	for _, g := range cc.ProxyGroups {
+		if g.Type != "relay" {
+			continue
+		}
+		hops, err := relayHops(g, sbc)
+		if err != nil {
+			sbc.report.skipProxy(g.Name, err)
+			continue
+		}
+		sbc.RelayHops = append(sbc.RelayHops, hops[:len(hops)-1]...)
+		sbc.Outbounds = append(sbc.Outbounds, hops[len(hops)-1])
+		sbc.report.addProxy(g.Name)
+	}
+}
+
+// 第一个节点只作为 detour 使用，可以是节点或者分组，其他节点必须是 outbound
+func relayHops(g ProxyGroup, sbc *SingBoxConfig) ([]singbox.Outbound, error) {
+	members := slices.DeleteFunc(slices.Clone(g.Proxies), func(name string) bool {
+		return name == "DIRECT"
+	})
+	if len(members) < 2 {
+		return nil, errors.New("relay needs at least two proxies")
+	}
+	var outbounds []singbox.Outbound
+	for _, name := range members[1:] {
+		idx := slices.IndexFunc(sbc.Outbounds, func(ob singbox.Outbound) bool {
+			return ob.Tag == name
+		})
+		if idx < 0 {
+			return nil, fmt.Errorf("relay proxy '%s' is not a supported outbound", name)
+		}
+		// 已经通过 dialer-proxy 或 shadow-tls 连接的节点不能再修改 detour
+		if optionsDetour(sbc.Outbounds[idx].Options) != "" {
+			return nil, fmt.Errorf("relay proxy '%s' already has detour", name)
+		}
+		outbounds = append(outbounds, sbc.Outbounds[idx])
+	}
+	// 检查通过后再分配中间节点的 tag，和其他节点重复时添加数字后缀
+	var hops []singbox.Outbound
+	detour := members[0]
+	for i, ob := range outbounds {
+		tag := g.Name
+		if i < len(outbounds)-1 {
+			tag = sbc.tags.unique(g.Name + "/" + ob.Tag)
+		}
+		hop, err := withDetour(ob, tag, detour)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, hop)
+		detour = tag
+	}
+	return hops, nil
+}
+
+// 复制 outbound 并修改 tag 和 detour
+func withDetour(ob singbox.Outbound, tag string, detour string) (singbox.Outbound, error) {
+	options, err := setDetour(ob.Options, detour)
+	if err != nil {
+		return ob, err
+	}
+	return singbox.Outbound{Type: ob.Type, Tag: tag, Options: options}, nil
+}
+
+func setDetour(options any, detour string) (json.RawMessage, error) {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	jh, err := JH.FromData(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := jh.Set("detour", detour); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(jh.Data()), nil
+}
+
+// 删除 detour 指向的节点或分组不存在的节点
+func checkDialerProxies(cc *ClashConfig, sbc *SingBoxConfig) {
+	var groupNames []string
+	for _, g := range cc.ProxyGroups {
+		switch g.Type {
+		case "select", "url-test", "fallback", "load-balance":
+			groupNames = append(groupNames, g.Name)
+		}
+	}
+	checkDetours(sbc, groupNames)
+}
+
+// 删除 detour 不是节点、relay 中间节点或者 groupNames 中的分组的节点
+// 删除节点后其他节点的 detour 可能也不存在，需要重复检查
+func checkDetours(sbc *SingBoxConfig, groupNames []string) {
+	for {
+		tags := slices.Concat(sbc.NodeTags(), groupNames)
+		for _, hop := range sbc.RelayHops {
+			tags = append(tags, hop.Tag)
+		}
+		removed := false
+		missing := func(tag string, options any, report bool) bool {
+			detour := optionsDetour(options)
+			if detour == "" || slices.Contains(tags, detour) {
+				return false
+			}
+			if report {
+				sbc.report.skipProxy(tag, fmt.Errorf("detour '%s' not exists", detour))
+			}
+			removed = true
+			return true
+		}
+		sbc.Outbounds = slices.DeleteFunc(sbc.Outbounds, func(ob singbox.Outbound) bool {
+			return missing(ob.Tag, ob.Options, true)
+		})
+		sbc.Endpoints = slices.DeleteFunc(sbc.Endpoints, func(ep singbox.Endpoint) bool {
+			return missing(ep.Tag, ep.Options, true)
+		})
+		sbc.RelayHops = slices.DeleteFunc(sbc.RelayHops, func(ob singbox.Outbound) bool {
+			return missing(ob.Tag, ob.Options, false)
+		})
+		if !removed {
+			return
+		}
+	}
+}
+
+func optionsDetour(options any) string {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return ""
+	}
+	return gjson.GetBytes(data, "detour").String()
+}
+
+// 检查 detour 是否有循环，分组和它包含的节点之间也算作依赖
+// 例如节点的 detour 是包含这个节点的分组时，选中这个节点会导致循环
+func checkDetourCycle(config *singbox.Config) error {
+	deps := make(map[string][]string)
+	addDeps := func(tag string, options any) {
+		data, err := json.Marshal(options)
+		if err != nil {
+			return
+		}
+		if detour := gjson.GetBytes(data, "detour").String(); detour != "" {
+			deps[tag] = append(deps[tag], detour)
+		}
+		for _, r := range gjson.GetBytes(data, "outbounds").Array() {
+			deps[tag] = append(deps[tag], r.String())
+		}
+	}
+	for _, ob := range config.Outbounds {
+		addDeps(ob.Tag, ob.Options)
+	}
+	for _, ep := range config.Endpoints {
+		addDeps(ep.Tag, ep.Options)
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	states := make(map[string]int)
+	var path []string
+	var visit func(tag string) error
+	visit = func(tag string) error {
+		switch states[tag] {
+		case visiting:
+			idx := slices.Index(path, tag)
+			return fmt.Errorf("detour cycle: %s", strings.Join(append(path[idx:], tag), " -> "))
+		case visited:
+			return nil
+		}
+		states[tag] = visiting
+		path = append(path, tag)
+		for _, dep := range deps[tag] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[tag] = visited
+		return nil
+	}
+	for _, ob := range config.Outbounds {
+		if err := visit(ob.Tag); err != nil {
+			return err
+		}
+	}
+	for _, ep := range config.Endpoints {
+		if err := visit(ep.Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,901 @@
+package converter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/follow1123/sing-box-ctl/singbox"
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	clashDirect = "DIRECT"
+	clashReject = "REJECT"
+
+	defaultTestUrl = "https://www.gstatic.com/generate_204"
+)
+
+// 将 sing-box 配置转换为 clash 配置，只转换节点、分组和规则
+// 不支持的节点会被忽略，分组和规则中对应的引用也会被删除，忽略的内容记录在报告中
+func ToClash(data []byte) ([]byte, *ExportReport, error) {
+	var config singbox.Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal sing-box config error:\n\t%w", err)
+	}
+	ce := newClashExporter(&config)
+	cc := &ClashConfig{}
+	ce.exportProxies(cc)
+	ce.exportProxyGroups(cc)
+	ce.exportRules(cc)
+	result, err := yaml.Marshal(cc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal clash config error:\n\t%w", err)
+	}
+	return result, ce.report, nil
+}
+
+// 导出 clash 配置的报告，按原因分组记录忽略的节点、分组、规则和规则集
+type ExportReport struct {
+	// 忽略的 outbound 和 endpoint
+	Proxies  map[string][]string `json:"proxies"`
+	Groups   map[string][]string `json:"groups"`
+	Rules    map[string][]string `json:"rules"`
+	RuleSets map[string][]string `json:"rule_sets"`
+}
+
+func newExportReport() *ExportReport {
+	return &ExportReport{
+		Proxies:  make(map[string][]string),
+		Groups:   make(map[string][]string),
+		Rules:    make(map[string][]string),
+		RuleSets: make(map[string][]string),
+	}
+}
+
+// 每个忽略的内容一行说明，按类型和原因排序
+func (r *ExportReport) Messages() []string {
+	var messages []string
+	for _, item := range []struct {
+		kind    string
+		skipped map[string][]string
+	}{
+		{"proxy", r.Proxies},
+		{"group", r.Groups},
+		{"rule", r.Rules},
+		{"rule set", r.RuleSets},
+	} {
+		reasons := slices.Sorted(maps.Keys(item.skipped))
+		for _, reason := range reasons {
+			for _, name := range item.skipped[reason] {
+				messages = append(messages, fmt.Sprintf("ignore %s '%s': %s", item.kind, name, reason))
+			}
+		}
+	}
+	return messages
+}
+
+func skipExport(m map[string][]string, name string, reason error) {
+	m[reason.Error()] = append(m[reason.Error()], name)
+}
+
+type clashExporter struct {
+	config *singbox.Config
+	report *ExportReport
+	// outbound 的 tag 对应到 clash 中的名称，不存在时表示不支持
+	targets map[string]string
+	// 规则集的 tag 对应的规则集
+	ruleSets map[string]singbox.RuleSet
+	resolved bool
+}
+
+func newClashExporter(config *singbox.Config) *clashExporter {
+	ce := &clashExporter{
+		config:   config,
+		report:   newExportReport(),
+		targets:  make(map[string]string),
+		ruleSets: make(map[string]singbox.RuleSet),
+	}
+	for _, ob := range config.Outbounds {
+		switch ob.Type {
+		case "direct":
+			ce.targets[ob.Tag] = clashDirect
+		case "block":
+			ce.targets[ob.Tag] = clashReject
+		case "selector", "urltest":
+			ce.targets[ob.Tag] = ob.Tag
+		}
+	}
+	if config.Route != nil {
+		for _, rs := range config.Route.RuleSet {
+			ce.ruleSets[rs.Tag] = rs
+		}
+	}
+	return ce
+}
+
+func (ce *clashExporter) exportProxies(cc *ClashConfig) {
+	// detour 指向的节点可能在后面，所有节点转换完成后再设置 dialer-proxy
+	detours := make(map[string]string)
+	defer func() {
+		for i := range cc.Proxies {
+			if target, ok := ce.targets[detours[cc.Proxies[i].Name]]; ok {
+				cc.Proxies[i].DialerProxy = target
+			}
+		}
+	}()
+	// shadow-tls 合并到通过它连接的 shadowsocks 节点中
+	shadowTls := make(map[string]singbox.ShadowTls)
+	for _, ob := range ce.config.Outbounds {
+		if ob.Type != "shadowtls" {
+			continue
+		}
+		var st singbox.ShadowTls
+		if err := decodeOptions(ob.Options, &st); err != nil {
+			skipExport(ce.report.Proxies, ob.Tag, err)
+			continue
+		}
+		shadowTls[ob.Tag] = st
+	}
+	for _, ob := range ce.config.Outbounds {
+		switch ob.Type {
+		case "direct", "block", "dns", "selector", "urltest", "shadowtls":
+			continue
+		}
+		p, err := outboundToClash(ob)
+		if err != nil {
+			skipExport(ce.report.Proxies, ob.Tag, err)
+			continue
+		}
+		detour := optionsDetour(ob.Options)
+		if st, ok := shadowTls[detour]; ok && ob.Type == "shadowsocks" {
+			if p.Plugin != "" {
+				skipExport(ce.report.Proxies, ob.Tag, errors.New("plugin with shadow-tls"))
+				continue
+			}
+			shadowTlsToClash(p, st)
+			detour = st.Detour
+		}
+		cc.Proxies = append(cc.Proxies, *p)
+		ce.targets[ob.Tag] = ob.Tag
+		detours[ob.Tag] = detour
+	}
+	for _, ep := range ce.config.Endpoints {
+		if ep.Type != "wireguard" {
+			skipExport(ce.report.Proxies, ep.Tag, fmt.Errorf("unsupport protocol '%s'", ep.Type))
+			continue
+		}
+		var wg singbox.WireGuard
+		if err := decodeOptions(ep.Options, &wg); err != nil {
+			skipExport(ce.report.Proxies, ep.Tag, err)
+			continue
+		}
+		p, err := wireGuardToClash(ep.Tag, wg)
+		if err != nil {
+			skipExport(ce.report.Proxies, ep.Tag, err)
+			continue
+		}
+		cc.Proxies = append(cc.Proxies, *p)
+		ce.targets[ep.Tag] = ep.Tag
+		detours[ep.Tag] = optionsDetour(ep.Options)
+	}
+}
+
+// selector 转换为 select，urltest 转换为 url-test，删除不支持的节点
+func (ce *clashExporter) exportProxyGroups(cc *ClashConfig) {
+	for _, ob := range ce.config.Outbounds {
+		var g ProxyGroup
+		var outbounds []string
+		switch ob.Type {
+		case "selector":
+			var selector singbox.Selector
+			if err := decodeOptions(ob.Options, &selector); err != nil {
+				skipExport(ce.report.Groups, ob.Tag, err)
+				continue
+			}
+			g = ProxyGroup{Name: ob.Tag, Type: "select"}
+			outbounds = selector.Outbounds
+		case "urltest":
+			var urlTest singbox.UrlTest
+			if err := decodeOptions(ob.Options, &urlTest); err != nil {
+				skipExport(ce.report.Groups, ob.Tag, err)
+				continue
+			}
+			// 和 sing-box 的默认值保持一致
+			g = ProxyGroup{
+				Name:      ob.Tag,
+				Type:      "url-test",
+				Url:       urlTest.Url,
+				Interval:  180,
+				Tolerance: urlTest.Tolerance,
+			}
+			if g.Url == "" {
+				g.Url = defaultTestUrl
+			}
+			if urlTest.Interval != "" {
+				interval, err := time.ParseDuration(urlTest.Interval)
+				if err != nil {
+					skipExport(ce.report.Groups, ob.Tag, fmt.Errorf("invalid interval '%s'", urlTest.Interval))
+					continue
+				}
+				g.Interval = int(interval.Seconds())
+			}
+			if g.Tolerance == 0 {
+				g.Tolerance = 50
+			}
+			outbounds = urlTest.Outbounds
+		default:
+			continue
+		}
+		for _, tag := range outbounds {
+			if target, ok := ce.targets[tag]; ok {
+				g.Proxies = append(g.Proxies, target)
+			}
+		}
+		// clash 的分组不能为空
+		if len(g.Proxies) == 0 {
+			g.Proxies = []string{clashDirect}
+		}
+		cc.ProxyGroups = append(cc.ProxyGroups, g)
+	}
+}
+
+// 转换路由规则，只转换引用规则集和局域网地址的规则，route.final 转换为 MATCH
+func (ce *clashExporter) exportRules(cc *ClashConfig) {
+	route := ce.config.Route
+	if route == nil {
+		route = &singbox.Route{}
+	}
+	for _, r := range route.Rules {
+		if r.Action == "resolve" {
+			ce.resolved = true
+			continue
+		}
+		if r.Type == "logical" || r.ClashMode != "" {
+			continue
+		}
+		target, ok := ce.ruleTarget(r)
+		if !ok {
+			continue
+		}
+		// 没有条件的规则匹配所有连接，后面的规则和 final 都不会生效
+		if matchAll(r) {
+			cc.Rules = append(cc.Rules, "MATCH,"+target)
+			return
+		}
+		if r.Invert {
+			ce.exportInvertRule(cc, r, target)
+			continue
+		}
+		if r.IpIsPrivate {
+			cc.Rules = append(cc.Rules, fmt.Sprintf("GEOIP,private,%s,no-resolve", target))
+		}
+		for _, tag := range r.RuleSet {
+			conditions, err := ce.ruleSetConditions(tag)
+			if err != nil {
+				skipExport(ce.report.RuleSets, tag, err)
+				continue
+			}
+			for _, c := range conditions {
+				isIp := strings.HasPrefix(c, "IP-CIDR") || strings.HasPrefix(c, "GEOIP,")
+				cc.Rules = append(cc.Rules, ce.withResolve(c+","+target, isIp))
+			}
+		}
+	}
+	final := route.Final
+	// 没有 final 时 sing-box 使用第一个 outbound
+	if final == "" && len(ce.config.Outbounds) > 0 {
+		final = ce.config.Outbounds[0].Tag
+	}
+	if target, ok := ce.targets[final]; ok {
+		cc.Rules = append(cc.Rules, "MATCH,"+target)
+	}
+}
+
+// 反选的规则使用 NOT 包含所有条件，规则集转换失败时忽略整条规则，避免反选的范围变大
+func (ce *clashExporter) exportInvertRule(cc *ClashConfig, r singbox.Rule, target string) {
+	var conditions []string
+	if r.IpIsPrivate {
+		conditions = append(conditions, "GEOIP,private")
+	}
+	for _, tag := range r.RuleSet {
+		ruleSetConditions, err := ce.ruleSetConditions(tag)
+		if err != nil {
+			rule, _ := json.Marshal(r)
+			skipExport(ce.report.Rules, string(rule), fmt.Errorf("rule set '%s': %w", tag, err))
+			return
+		}
+		conditions = append(conditions, ruleSetConditions...)
+	}
+	if len(conditions) > 0 {
+		cc.Rules = append(cc.Rules, invertCondition(logicalCondition("OR", conditions), true)+","+target)
+	}
+}
+
+func matchAll(r singbox.Rule) bool {
+	return reflect.DeepEqual(r, singbox.Rule{Action: r.Action, Outbound: r.Outbound, Method: r.Method})
+}
+
+// 规则指向的 clash 节点或分组，不支持的 action 返回 false
+func (ce *clashExporter) ruleTarget(r singbox.Rule) (string, bool) {
+	switch r.Action {
+	case "", "route":
+		target, ok := ce.targets[r.Outbound]
+		return target, ok
+	case "reject":
+		if r.Method == "drop" {
+			return "REJECT-DROP", true
+		}
+		return clashReject, true
+	default:
+		return "", false
+	}
+}
+
+// 内联规则集转换为规则条件，geosite、geoip 远程规则集转换为 GEOSITE、GEOIP 条件，不包含规则指向的目标
+func (ce *clashExporter) ruleSetConditions(tag string) ([]string, error) {
+	rs, ok := ce.ruleSets[tag]
+	if !ok {
+		return nil, errors.New("rule set not exists")
+	}
+	if rs.Type != "inline" {
+		if code, ok := strings.CutPrefix(tag, "geosite-"); ok {
+			return []string{"GEOSITE," + code}, nil
+		}
+		if code, ok := strings.CutPrefix(tag, "geoip-"); ok {
+			return []string{"GEOIP," + code}, nil
+		}
+		return nil, fmt.Errorf("unsupport rule set type '%s'", rs.Type)
+	}
+	var conditions []string
+	for _, hr := range rs.Rules {
+		hrConditions, err := headlessConditions(hr)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, hrConditions...)
+	}
+	return conditions, nil
+}
+
+// 之前没有解析域名的 IP 规则添加 no-resolve
+func (ce *clashExporter) withResolve(rule string, isIp bool) string {
+	if isIp && !ce.resolved {
+		return rule + ",no-resolve"
+	}
+	return rule
+}
+
+// 转换规则集中的规则为 clash 规则条件，不包含规则指向的目标
+// 只有一类条件时每个值作为单独的规则，否则使用 AND 组合各类条件
+func headlessConditions(hr singbox.HeadlessRule) ([]string, error) {
+	if hr.Type == "logical" {
+		var subs []string
+		for _, sub := range hr.Rules {
+			conditions, err := headlessConditions(sub)
+			if err != nil {
+				return nil, err
+			}
+			subs = append(subs, logicalCondition("OR", conditions))
+		}
+		return []string{invertCondition(logicalCondition(strings.ToUpper(hr.Mode), subs), hr.Invert)}, nil
+	}
+	groups, err := conditionGroups(hr)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("empty rule")
+	}
+	if len(groups) == 1 && !hr.Invert {
+		return groups[0], nil
+	}
+	var subs []string
+	for _, g := range groups {
+		subs = append(subs, logicalCondition("OR", g))
+	}
+	return []string{invertCondition(logicalCondition("AND", subs), hr.Invert)}, nil
+}
+
+// 按 sing-box 的匹配逻辑对条件分组，组内是或的关系，组之间是与的关系
+func conditionGroups(hr singbox.HeadlessRule) ([][]string, error) {
+	var destination []string
+	for _, v := range hr.Domain {
+		destination = append(destination, "DOMAIN,"+v)
+	}
+	for _, v := range hr.DomainSuffix {
+		destination = append(destination, "DOMAIN-SUFFIX,"+v)
+	}
+	for _, v := range hr.DomainKeyword {
+		destination = append(destination, "DOMAIN-KEYWORD,"+v)
+	}
+	for _, v := range hr.DomainRegex {
+		destination = append(destination, "DOMAIN-REGEX,"+v)
+	}
+	for _, v := range hr.IpCidr {
+		destination = append(destination, ipCidrCondition("IP-CIDR", v))
+	}
+	var source []string
+	for _, v := range hr.SourceIpCidr {
+		source = append(source, "SRC-IP-CIDR,"+v)
+	}
+	port, err := portConditions("DST-PORT", hr.Port, hr.PortRange)
+	if err != nil {
+		return nil, err
+	}
+	sourcePort, err := portConditions("SRC-PORT", hr.SourcePort, hr.SourcePortRange)
+	if err != nil {
+		return nil, err
+	}
+	var network []string
+	for _, v := range hr.Network {
+		network = append(network, "NETWORK,"+strings.ToUpper(v))
+	}
+	var processName []string
+	for _, v := range hr.ProcessName {
+		processName = append(processName, "PROCESS-NAME,"+v)
+	}
+	var processPath []string
+	for _, v := range hr.ProcessPath {
+		processPath = append(processPath, "PROCESS-PATH,"+v)
+	}
+	var groups [][]string
+	for _, g := range [][]string{destination, port, source, sourcePort, network, processName, processPath} {
+		if len(g) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups, nil
+}
+
+// ipv6 地址使用 IP-CIDR6
+func ipCidrCondition(typ string, cidr string) string {
+	if strings.Contains(cidr, ":") {
+		typ += "6"
+	}
+	return typ + "," + cidr
+}
+
+// 转换端口，sing-box 的端口范围格式为 1000:2000，clash 为 1000-2000
+func portConditions(typ string, ports []uint16, ranges []string) ([]string, error) {
+	var conditions []string
+	for _, p := range ports {
+		conditions = append(conditions, fmt.Sprintf("%s,%d", typ, p))
+	}
+	for _, r := range ranges {
+		start, end, _ := strings.Cut(r, ":")
+		if start == "" || end == "" {
+			return nil, fmt.Errorf("unsupport port range '%s'", r)
+		}
+		conditions = append(conditions, fmt.Sprintf("%s,%s-%s", typ, start, end))
+	}
+	return conditions, nil
+}
+
+// 逻辑规则格式为 AND,((DOMAIN,a.com),(NETWORK,UDP))，只有一个条件时直接返回
+func logicalCondition(mode string, conditions []string) string {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return mode + ",((" + strings.Join(conditions, "),(") + "))"
+}
+
+func invertCondition(condition string, invert bool) string {
+	if !invert {
+		return condition
+	}
+	return "NOT,((" + condition + "))"
+}
+
+// 将 Options 解析到协议对应的结构体
+func decodeOptions(options any, v any) error {
+	if options == nil {
+		return nil
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func outboundToClash(ob singbox.Outbound) (*Proxy, error) {
+	switch ob.Type {
+	case "shadowsocks":
+		var ss singbox.Shadowsocks
+		if err := decodeOptions(ob.Options, &ss); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:     ob.Tag,
+			Type:     "ss",
+			Server:   ss.Server,
+			Port:     ss.ServerPort,
+			Cipher:   ss.Method,
+			Password: ss.Password,
+			Udp:      ss.Network != "tcp",
+		}
+		if ss.Plugin != "" {
+			plugin, opts, err := pluginToClash(ss.Plugin, ss.PluginOpts)
+			if err != nil {
+				return nil, err
+			}
+			p.Plugin = plugin
+			p.PluginOpts = opts
+		}
+		return p, nil
+	case "trojan":
+		var trojan singbox.Trojan
+		if err := decodeOptions(ob.Options, &trojan); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:     ob.Tag,
+			Type:     "trojan",
+			Server:   trojan.Server,
+			Port:     trojan.ServerPort,
+			Password: trojan.Password,
+			Udp:      trojan.Network != "tcp",
+		}
+		tlsToClash(p, trojan.Tls)
+		if err := transportToClash(p, trojan.Transport, trojan.Tls); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "vmess":
+		var vmess singbox.Vmess
+		if err := decodeOptions(ob.Options, &vmess); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:    ob.Tag,
+			Type:    "vmess",
+			Server:  vmess.Server,
+			Port:    vmess.ServerPort,
+			Uuid:    vmess.Uuid,
+			AlterId: vmess.AlterId,
+			Cipher:  vmess.Security,
+			Udp:     vmess.Network != "tcp",
+		}
+		if p.Cipher == "" {
+			p.Cipher = "auto"
+		}
+		tlsToClash(p, vmess.Tls)
+		if err := transportToClash(p, vmess.Transport, vmess.Tls); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "vless":
+		var vless singbox.Vless
+		if err := decodeOptions(ob.Options, &vless); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:           ob.Tag,
+			Type:           "vless",
+			Server:         vless.Server,
+			Port:           vless.ServerPort,
+			Uuid:           vless.Uuid,
+			Flow:           vless.Flow,
+			PacketEncoding: vless.PacketEncoding,
+			Udp:            vless.Network != "tcp",
+		}
+		tlsToClash(p, vless.Tls)
+		if err := transportToClash(p, vless.Transport, vless.Tls); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "hysteria2":
+		var hy2 singbox.Hysteria2
+		if err := decodeOptions(ob.Options, &hy2); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:     ob.Tag,
+			Type:     "hysteria2",
+			Server:   hy2.Server,
+			Port:     hy2.ServerPort,
+			Password: hy2.Password,
+		}
+		var ports []string
+		for _, item := range hy2.ServerPorts {
+			start, end, _ := strings.Cut(item, ":")
+			if start == end {
+				ports = append(ports, start)
+			} else {
+				ports = append(ports, start+"-"+end)
+			}
+		}
+		p.Ports = strings.Join(ports, ",")
+		if hy2.HopInterval != "" {
+			interval, err := time.ParseDuration(hy2.HopInterval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hop interval '%s'", hy2.HopInterval)
+			}
+			p.HopInterval = int(interval.Seconds())
+		}
+		if hy2.UpMbps > 0 {
+			p.Up = strconv.Itoa(hy2.UpMbps)
+		}
+		if hy2.DownMbps > 0 {
+			p.Down = strconv.Itoa(hy2.DownMbps)
+		}
+		if hy2.Obfs != nil {
+			p.Obfs = hy2.Obfs.Type
+			p.ObfsPassword = hy2.Obfs.Password
+		}
+		tlsToClash(p, hy2.Tls)
+		return p, nil
+	case "tuic":
+		var tuic singbox.Tuic
+		if err := decodeOptions(ob.Options, &tuic); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:                 ob.Tag,
+			Type:                 "tuic",
+			Server:               tuic.Server,
+			Port:                 tuic.ServerPort,
+			Uuid:                 tuic.Uuid,
+			Password:             tuic.Password,
+			CongestionController: tuic.CongestionControl,
+			UdpRelayMode:         tuic.UdpRelayMode,
+			ReduceRtt:            tuic.ZeroRttHandshake,
+		}
+		if tuic.Heartbeat != "" {
+			heartbeat, err := time.ParseDuration(tuic.Heartbeat)
+			if err != nil {
+				return nil, fmt.Errorf("invalid heartbeat '%s'", tuic.Heartbeat)
+			}
+			p.HeartbeatInterval = int(heartbeat.Milliseconds())
+		}
+		tlsToClash(p, tuic.Tls)
+		return p, nil
+	case "anytls":
+		var anyTls singbox.AnyTls
+		if err := decodeOptions(ob.Options, &anyTls); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:           ob.Tag,
+			Type:           "anytls",
+			Server:         anyTls.Server,
+			Port:           anyTls.ServerPort,
+			Password:       anyTls.Password,
+			MinIdleSession: anyTls.MinIdleSession,
+		}
+		if anyTls.IdleSessionCheckInterval != "" {
+			interval, err := time.ParseDuration(anyTls.IdleSessionCheckInterval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid idle session check interval '%s'", anyTls.IdleSessionCheckInterval)
+			}
+			p.IdleSessionCheckInterval = int(interval.Seconds())
+		}
+		if anyTls.IdleSessionTimeout != "" {
+			timeout, err := time.ParseDuration(anyTls.IdleSessionTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid idle session timeout '%s'", anyTls.IdleSessionTimeout)
+			}
+			p.IdleSessionTimeout = int(timeout.Seconds())
+		}
+		tlsToClash(p, anyTls.Tls)
+		return p, nil
+	case "ssh":
+		var ssh singbox.Ssh
+		if err := decodeOptions(ob.Options, &ssh); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:                 ob.Tag,
+			Type:                 "ssh",
+			Server:               ssh.Server,
+			Port:                 ssh.ServerPort,
+			Username:             ssh.User,
+			Password:             ssh.Password,
+			PrivateKey:           ssh.PrivateKeyPath,
+			PrivateKeyPassphrase: ssh.PrivateKeyPassphrase,
+			HostKey:              ssh.HostKey,
+			HostKeyAlgorithms:    ssh.HostKeyAlgorithms,
+		}
+		if len(ssh.PrivateKey) > 0 {
+			p.PrivateKey = strings.Join(ssh.PrivateKey, "\n")
+		}
+		return p, nil
+	case "http":
+		var http singbox.Http
+		if err := decodeOptions(ob.Options, &http); err != nil {
+			return nil, err
+		}
+		p := &Proxy{
+			Name:     ob.Tag,
+			Type:     "http",
+			Server:   http.Server,
+			Port:     http.ServerPort,
+			Username: http.Username,
+			Password: http.Password,
+		}
+		for name, value := range http.Headers {
+			if len(value) == 0 {
+				continue
+			}
+			if p.Headers == nil {
+				p.Headers = make(map[string]string)
+			}
+			p.Headers[name] = value[0]
+		}
+		tlsToClash(p, http.Tls)
+		return p, nil
+	case "socks":
+		var socks singbox.Socks
+		if err := decodeOptions(ob.Options, &socks); err != nil {
+			return nil, err
+		}
+		if socks.Version != "" && socks.Version != "5" {
+			return nil, fmt.Errorf("unsupport socks version '%s'", socks.Version)
+		}
+		return &Proxy{
+			Name:     ob.Tag,
+			Type:     "socks5",
+			Server:   socks.Server,
+			Port:     socks.ServerPort,
+			Username: socks.Username,
+			Password: socks.Password,
+			Udp:      socks.Network != "tcp",
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupport protocol '%s'", ob.Type)
+	}
+}
+
+// 转换 tls 配置，vmess、vless 使用 servername，其他协议使用 sni，tls 版本和 ech 配置文件不支持转换
+func tlsToClash(p *Proxy, tls *singbox.Tls) {
+	if tls == nil || !tls.Enabled {
+		return
+	}
+	switch p.Type {
+	case "vmess", "vless":
+		p.Tls = true
+		p.ServerName = tls.ServerName
+	case "http":
+		p.Tls = true
+		p.Sni = tls.ServerName
+	default:
+		p.Sni = tls.ServerName
+	}
+	p.SkipCertVerify = tls.Insecure
+	p.Alpn = tls.Alpn
+	p.Ca = tls.CertificatePath
+	p.CaStr = strings.Join(tls.Certificate, "\n")
+	if tls.Ech != nil && tls.Ech.Enabled {
+		// clash 使用 base64 编码的内容，去掉 PEM 的开始和结束行
+		var config []string
+		for _, line := range tls.Ech.Config {
+			if !strings.HasPrefix(line, "-----") {
+				config = append(config, line)
+			}
+		}
+		p.EchOpts = &EchOpts{Enable: true, Config: strings.Join(config, "")}
+	}
+	if tls.Utls != nil {
+		p.ClientFingerprint = tls.Utls.Fingerprint
+	}
+	if tls.Reality != nil && tls.Reality.Enabled {
+		p.RealityOpts = &RealityOpts{
+			PublicKey: tls.Reality.PublicKey,
+			ShortId:   tls.Reality.ShortId,
+		}
+	}
+}
+
+// 转换传输层，http 传输层开启 tls 时对应 clash 的 h2
+func transportToClash(p *Proxy, t *singbox.Transport, tls *singbox.Tls) error {
+	if t == nil {
+		return nil
+	}
+	switch t.Type {
+	case "ws", "httpupgrade":
+		p.Network = "ws"
+		opts := &WsOpts{Path: t.Path}
+		if t.Type == "httpupgrade" {
+			opts.V2rayHttpUpgrade = true
+			if len(t.Host) > 0 {
+				opts.Headers = map[string]string{"Host": t.Host[0]}
+			}
+		} else {
+			opts.MaxEarlyData = t.MaxEarlyData
+			opts.EarlyDataHeaderName = t.EarlyDataHeaderName
+		}
+		for name, value := range t.Headers {
+			if len(value) == 0 {
+				continue
+			}
+			if opts.Headers == nil {
+				opts.Headers = make(map[string]string)
+			}
+			opts.Headers[name] = value[0]
+		}
+		p.WsOpts = opts
+	case "http":
+		if tls != nil && tls.Enabled {
+			p.Network = "h2"
+			p.H2Opts = &H2Opts{Host: t.Host, Path: t.Path}
+			return nil
+		}
+		p.Network = "http"
+		opts := &HttpOpts{Method: t.Method}
+		if t.Path != "" {
+			opts.Path = []string{t.Path}
+		}
+		if len(t.Host) > 0 || len(t.Headers) > 0 {
+			opts.Headers = make(map[string][]string)
+		}
+		if len(t.Host) > 0 {
+			opts.Headers["Host"] = t.Host
+		}
+		for name, value := range t.Headers {
+			opts.Headers[name] = value
+		}
+		p.HttpOpts = opts
+	case "grpc":
+		p.Network = "grpc"
+		p.GrpcOpts = &GrpcOpts{GrpcServiceName: t.ServiceName}
+	default:
+		return fmt.Errorf("unsupport transport '%s'", t.Type)
+	}
+	return nil
+}
+
+// 只有一个 peer 时使用节点本身的属性，否则使用 peers
+func wireGuardToClash(tag string, wg singbox.WireGuard) (*Proxy, error) {
+	if len(wg.Peers) == 0 {
+		return nil, errors.New("no peers")
+	}
+	p := &Proxy{
+		Name:                tag,
+		Type:                "wireguard",
+		PrivateKey:          wg.PrivateKey,
+		Mtu:                 wg.Mtu,
+		PersistentKeepalive: wg.Peers[0].PersistentKeepaliveInterval,
+		Udp:                 true,
+	}
+	for _, addr := range wg.Address {
+		ip, _, _ := strings.Cut(addr, "/")
+		if strings.Contains(ip, ":") {
+			p.Ipv6 = ip
+		} else {
+			p.Ip = ip
+		}
+	}
+	if len(wg.Peers) == 1 {
+		peer := wg.Peers[0]
+		p.Server = peer.Address
+		p.Port = peer.Port
+		p.PublicKey = peer.PublicKey
+		p.PreSharedKey = peer.PreSharedKey
+		p.AllowedIps = peer.AllowedIps
+		if len(peer.Reserved) > 0 {
+			p.Reserved = peer.Reserved
+		}
+		return p, nil
+	}
+	for _, peer := range wg.Peers {
+		peerOpts := WireGuardPeerOpts{
+			Server:       peer.Address,
+			Port:         peer.Port,
+			PublicKey:    peer.PublicKey,
+			PreSharedKey: peer.PreSharedKey,
+			AllowedIps:   peer.AllowedIps,
+		}
+		if len(peer.Reserved) > 0 {
+			peerOpts.Reserved = peer.Reserved
+		}
+		p.Peers = append(p.Peers, peerOpts)
+	}
+	return p, nil
+}
@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	errNodeExcluded  = errors.New("excluded by node filter")
+	errNodeEmptyName = errors.New("empty name after rename")
+)
+
+// 节点过滤和重命名规则，都是正则表达式
+// Include 不为空时只保留匹配的节点，Exclude 排除匹配的节点，过滤使用的是重命名之前的名称
+type NodeFilter struct {
+	Include []string
+	Exclude []string
+	Rename  []RenameRule
+}
+
+// 将节点名称中匹配 Pattern 的部分替换为 Replace，Replace 可以使用 $1 引用分组
+type RenameRule struct {
+	Pattern string
+	Replace string
+}
+
+type renameMatcher struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// 编译后的节点过滤规则，为 nil 时不过滤
+type nodeMatcher struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	rename  []renameMatcher
+}
+
+func (f NodeFilter) compile() (*nodeMatcher, error) {
+	if len(f.Include) == 0 && len(f.Exclude) == 0 && len(f.Rename) == 0 {
+		return nil, nil
+	}
+	nm := &nodeMatcher{}
+	for _, pattern := range f.Include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern '%s'", pattern)
+		}
+		nm.include = append(nm.include, re)
+	}
+	for _, pattern := range f.Exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern '%s'", pattern)
+		}
+		nm.exclude = append(nm.exclude, re)
+	}
+	for _, r := range f.Rename {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename pattern '%s'", r.Pattern)
+		}
+		nm.rename = append(nm.rename, renameMatcher{re: re, replace: r.Replace})
+	}
+	return nm, nil
+}
+
+// 返回节点重命名后的名称，节点被过滤时返回错误
+func (nm *nodeMatcher) apply(name string) (string, error) {
+	if nm == nil {
+		return name, nil
+	}
+	match := func(re *regexp.Regexp) bool {
+		return re.MatchString(name)
+	}
+	if len(nm.include) > 0 && !slices.ContainsFunc(nm.include, match) {
+		return "", errNodeExcluded
+	}
+	if slices.ContainsFunc(nm.exclude, match) {
+		return "", errNodeExcluded
+	}
+	for _, r := range nm.rename {
+		name = r.re.ReplaceAllString(name, r.replace)
+	}
+	if name == "" {
+		return "", errNodeEmptyName
+	}
+	return name, nil
+}
+
+// 过滤和重命名 clash 配置中的节点并分配不重复的名称，同时修改 dialer-proxy、分组和规则中引用的节点名称
+// 重命名后或者原来的名称和内置 outbound、其他节点重复时添加数字后缀，同名节点的引用指向第一个节点
+func filterProxies(cc *ClashConfig, nm *nodeMatcher, ta *tagAllocator) {
+	renamed := make(map[string]string)
+	proxies := make([]Proxy, 0, len(cc.Proxies))
+	for _, p := range cc.Proxies {
+		name, err := nm.apply(p.Name)
+		if err != nil {
+			ta.report.skipProxy(p.Name, err)
+			continue
+		}
+		name = ta.unique(name)
+		if _, ok := renamed[p.Name]; !ok {
+			renamed[p.Name] = name
+		}
+		p.Name = name
+		proxies = append(proxies, p)
+	}
+	cc.Proxies = proxies
+	renameReferences(cc, renamed)
+}
+
+// 修改 dialer-proxy、分组和规则中引用的节点或分组名称
+func renameReferences(cc *ClashConfig, renamed map[string]string) {
+	if len(renamed) == 0 {
+		return
+	}
+	for i := range cc.Proxies {
+		if newName, ok := renamed[cc.Proxies[i].DialerProxy]; ok {
+			cc.Proxies[i].DialerProxy = newName
+		}
+	}
+	for i := range cc.ProxyGroups {
+		g := &cc.ProxyGroups[i]
+		for j, name := range g.Proxies {
+			if newName, ok := renamed[name]; ok {
+				g.Proxies[j] = newName
+			}
+		}
+	}
+	for i, r := range cc.Rules {
+		items := splitRule(r)
+		// MATCH 规则的目标是第二项，其他规则是第三项
+		idx := 2
+		if len(items) == 2 {
+			idx = 1
+		}
+		if idx >= len(items) {
+			continue
+		}
+		if newName, ok := renamed[items[idx]]; ok {
+			items[idx] = newName
+			cc.Rules[i] = strings.Join(items, ",")
+		}
+	}
+}
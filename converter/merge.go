@@ -0,0 +1,134 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// 合并的订阅，Filter 为这个订阅的节点过滤和重命名规则
+type Source struct {
+	Name   string
+	Data   []byte
+	Filter NodeFilter
+}
+
+// 合并多个订阅生成一个配置，第一个订阅保留分组、规则和 WithClashSettings 转换的设置，其他订阅只合并节点
+// 每个订阅生成一个以订阅名称为 tag 的 selector 分组和对应的 urltest 分组，添加到节点选择中，名称重复时添加数字后缀
+// 节点的 tag 重复时添加订阅名称作为前缀，使用 Source 的 Filter 代替 WithNodeFilter
+func ConvertMerged(sources []Source, opts ...Option) ([]byte, *Report, error) {
+	if len(sources) == 0 {
+		return nil, nil, errors.New("no sources to merge")
+	}
+	o := newOptions(opts)
+	var sbc *SingBoxConfig
+	var names []string
+	nodeTags := make(map[string][]string)
+	for _, s := range sources {
+		if slices.Contains(names, s.Name) {
+			return nil, nil, fmt.Errorf("duplicate source '%s'", s.Name)
+		}
+		names = append(names, s.Name)
+		nodes, err := s.Filter.compile()
+		if err != nil {
+			return nil, nil, fmt.Errorf("compile node filter of '%s' error: \n\t%w", s.Name, err)
+		}
+		o.nodes = nodes
+		current, err := toSingBox(s.Data, o)
+		if err != nil {
+			return nil, nil, fmt.Errorf("convert source '%s' error: \n\t%w", s.Name, err)
+		}
+		if sbc == nil {
+			sbc = current
+			nodeTags[s.Name] = current.NodeTags()
+		} else {
+			nodeTags[s.Name] = mergeNodes(sbc, current, s.Name)
+		}
+	}
+
+	// 分组的 tag 和节点或分组重复时添加数字后缀
+	for _, s := range sources {
+		// 没有节点的订阅不生成分组
+		if len(nodeTags[s.Name]) == 0 {
+			continue
+		}
+		tag := sbc.tags.unique(s.Name)
+		sbc.ProviderGroups = append(sbc.ProviderGroups, nodeGroups(tag, sbc.tags.unique(tag+"-"+tagAuto), nodeTags[s.Name])...)
+	}
+	return generate(sbc, o)
+}
+
+// 合并其他订阅的节点和转换报告，返回合并的节点 tag
+// tag 重复时添加订阅名称作为前缀并记录到转换报告，detour 同时修改，detour 指向分组的节点会被删除
+func mergeNodes(dst *SingBoxConfig, src *SingBoxConfig, name string) []string {
+	renamed := make(map[string]string)
+	unique := func(tag string) string {
+		result := tag
+		if dst.tags.contains(result) {
+			result = fmt.Sprintf("[%s] %s", name, tag)
+		}
+		result = dst.tags.alloc(result)
+		renamed[tag] = result
+		if result != tag {
+			src.report.renameProxy(tag, result)
+		}
+		return result
+	}
+	for i := range src.Outbounds {
+		src.Outbounds[i].Tag = unique(src.Outbounds[i].Tag)
+	}
+	for i := range src.Endpoints {
+		src.Endpoints[i].Tag = unique(src.Endpoints[i].Tag)
+	}
+	for i := range src.RelayHops {
+		src.RelayHops[i].Tag = unique(src.RelayHops[i].Tag)
+	}
+	for i, converted := range src.report.Proxies.Converted {
+		if tag, ok := renamed[converted]; ok {
+			src.report.Proxies.Converted[i] = tag
+		}
+	}
+
+	// 修改 detour，指向的节点不存在时保持不变，由 checkDetours 删除
+	rename := func(tag string, options *any) bool {
+		target, ok := renamed[optionsDetour(*options)]
+		if !ok {
+			return false
+		}
+		result, err := setDetour(*options, target)
+		if err != nil {
+			src.report.skipProxy(tag, err)
+			return true
+		}
+		*options = result
+		return false
+	}
+	outbounds := src.Outbounds[:0]
+	for _, ob := range src.Outbounds {
+		if !rename(ob.Tag, &ob.Options) {
+			outbounds = append(outbounds, ob)
+		}
+	}
+	src.Outbounds = outbounds
+	endpoints := src.Endpoints[:0]
+	for _, ep := range src.Endpoints {
+		if !rename(ep.Tag, &ep.Options) {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	src.Endpoints = endpoints
+	hops := src.RelayHops[:0]
+	for _, ob := range src.RelayHops {
+		if !rename(ob.Tag, &ob.Options) {
+			hops = append(hops, ob)
+		}
+	}
+	src.RelayHops = hops
+	checkDetours(src, nil)
+
+	dst.Outbounds = append(dst.Outbounds, src.Outbounds...)
+	dst.Endpoints = append(dst.Endpoints, src.Endpoints...)
+	dst.RelayHops = append(dst.RelayHops, src.RelayHops...)
+	dst.report.mergeProxies(src.report)
+	return src.NodeTags()
+}
@@ -0,0 +1,128 @@
+package converter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	JH "github.com/follow1123/sing-box-ctl/jsonhandler"
+	"github.com/follow1123/sing-box-ctl/singbox"
+	"github.com/tidwall/gjson"
+)
+
+// 不是节点的 outbound 类型，这类 outbound 由模板生成
+var nonNodeOutboundTypes = []string{"selector", "urltest", "direct", "block", "dns"}
+
+// 订阅内容是包含 outbounds 的 sing-box 配置时返回 json 处理器
+func decodeNative(data []byte) (*JH.JsonHandler, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		return nil, false
+	}
+	jh, err := JH.FromData(data)
+	if err != nil {
+		return nil, false
+	}
+	if outbounds, exists := jh.GetResult("outbounds"); !exists || !outbounds.IsArray() {
+		return nil, false
+	}
+	return jh, true
+}
+
+// 提取 sing-box 配置中的节点，节点配置原样保留，分组和规则使用模板生成
+func nativeToSingBox(jh *JH.JsonHandler, nm *nodeMatcher) (*SingBoxConfig, error) {
+	sbc := newSingBoxConfig()
+	var outbounds, endpoints []gjson.Result
+	if result, exists := jh.GetResult("outbounds"); exists {
+		outbounds = slices.DeleteFunc(result.Array(), func(r gjson.Result) bool {
+			return slices.Contains(nonNodeOutboundTypes, r.Get("type").String())
+		})
+	}
+	if result, exists := jh.GetResult("endpoints"); exists {
+		endpoints = result.Array()
+	}
+
+	// 过滤和重命名节点，记录原来的 tag 对应的新 tag，tag 重复时 detour 指向第一个节点
+	renamed := make(map[string]string)
+	filter := func(results []gjson.Result) ([]gjson.Result, []string) {
+		var kept []gjson.Result
+		var tags []string
+		for _, r := range results {
+			tag := r.Get("tag").String()
+			newTag, err := nm.apply(tag)
+			if err != nil {
+				sbc.report.skipProxy(tag, err)
+				continue
+			}
+			newTag = sbc.tags.unique(newTag)
+			if _, ok := renamed[tag]; !ok {
+				renamed[tag] = newTag
+			}
+			kept = append(kept, r)
+			tags = append(tags, newTag)
+		}
+		return kept, tags
+	}
+	outbounds, outboundTags := filter(outbounds)
+	endpoints, endpointTags := filter(endpoints)
+
+	for i, r := range outbounds {
+		tag := r.Get("tag").String()
+		raw, err := nativeOptions(r, renamed)
+		if err != nil {
+			sbc.report.skipProxy(tag, err)
+			continue
+		}
+		sbc.Outbounds = append(sbc.Outbounds, singbox.Outbound{Type: r.Get("type").String(), Tag: outboundTags[i], Options: raw})
+		sbc.report.addProxy(outboundTags[i])
+	}
+	for i, r := range endpoints {
+		tag := r.Get("tag").String()
+		raw, err := nativeOptions(r, renamed)
+		if err != nil {
+			sbc.report.skipProxy(tag, err)
+			continue
+		}
+		sbc.Endpoints = append(sbc.Endpoints, singbox.Endpoint{Type: r.Get("type").String(), Tag: endpointTags[i], Options: raw})
+		sbc.report.addProxy(endpointTags[i])
+	}
+	// detour 指向的节点被跳过时也跳过这个节点
+	checkDetours(sbc, nil)
+	if len(sbc.NodeTags()) == 0 {
+		return nil, errors.New("no outbounds in sing-box config")
+	}
+	return sbc, nil
+}
+
+// 返回除 type 和 tag 外的字段，detour 指向的不是节点时返回错误，指向重命名的节点时使用新的名称
+func nativeOptions(r gjson.Result, renamed map[string]string) (json.RawMessage, error) {
+	if r.Get("type").String() == "" || r.Get("tag").String() == "" {
+		return nil, errors.New("no type or tag")
+	}
+	jh, err := JH.FromData([]byte(r.Raw))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"type", "tag"} {
+		if err := jh.Delete(key); err != nil {
+			return nil, err
+		}
+	}
+	if detour, exists := jh.GetString("detour"); exists {
+		newTag, ok := renamed[detour]
+		if !ok {
+			return nil, fmt.Errorf("detour '%s' not exists", detour)
+		}
+		if err := jh.Set("detour", newTag); err != nil {
+			return nil, err
+		}
+	}
+	if err := jh.Compact(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(jh.Data())) == "{}" {
+		return nil, errors.New("no options")
+	}
+	return json.RawMessage(jh.Data()), nil
+}
@@ -0,0 +1,152 @@
+package converter
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/follow1123/sing-box-ctl/singbox"
+)
+
+// 转换 obfs、v2ray-plugin 插件为 sing-box 的插件名称和 SIP003 格式的插件参数
+func convertPlugin(p Proxy) (string, string, error) {
+	opts := p.PluginOpts
+	if opts == nil {
+		opts = &PluginOpts{}
+	}
+	switch p.Plugin {
+	case "":
+		return "", "", nil
+	case "obfs":
+		mode := cmp.Or(opts.Mode, "http")
+		if mode != "http" && mode != "tls" {
+			return "", "", fmt.Errorf("unsupport obfs mode '%s'", mode)
+		}
+		args := []string{"obfs=" + mode}
+		if opts.Host != "" {
+			args = append(args, "obfs-host="+opts.Host)
+		}
+		return "obfs-local", strings.Join(args, ";"), nil
+	case "v2ray-plugin":
+		if opts.Mode != "" && opts.Mode != "websocket" {
+			return "", "", fmt.Errorf("unsupport v2ray-plugin mode '%s'", opts.Mode)
+		}
+		if opts.SkipCertVerify {
+			return "", "", errors.New("v2ray-plugin skip-cert-verify is not supported")
+		}
+		var args []string
+		if opts.Tls {
+			args = append(args, "tls")
+		}
+		// 插件只能设置 Host 请求头
+		host := opts.Host
+		for name, value := range opts.Headers {
+			if host == "" && strings.EqualFold(name, "host") {
+				host = value
+			}
+		}
+		if host != "" {
+			args = append(args, "host="+host)
+		}
+		if opts.Path != "" {
+			args = append(args, "path="+opts.Path)
+		}
+		// sing-box 默认开启 mux
+		if opts.Mux {
+			args = append(args, "mux=1")
+		} else {
+			args = append(args, "mux=0")
+		}
+		return "v2ray-plugin", strings.Join(args, ";"), nil
+	default:
+		return "", "", fmt.Errorf("unsupport plugin '%s'", p.Plugin)
+	}
+}
+
+// shadow-tls 转换为单独的 outbound，tag 为 节点名称/shadow-tls（重复时添加数字后缀），shadowsocks 通过 detour 使用
+func convertShadowTls(p Proxy) (singbox.Outbound, error) {
+	opts := p.PluginOpts
+	if opts == nil {
+		return singbox.Outbound{}, errors.New("no shadow-tls plugin-opts")
+	}
+	if opts.Fingerprint != "" {
+		return singbox.Outbound{}, errTlsFingerprint
+	}
+	// clash 默认使用 v2
+	version := cmp.Or(opts.Version, 2)
+	if version > 1 && opts.Password == "" {
+		return singbox.Outbound{}, fmt.Errorf("shadow-tls v%d needs password", version)
+	}
+	tls := &singbox.Tls{
+		Enabled:    true,
+		ServerName: opts.Host,
+		Insecure:   opts.SkipCertVerify,
+		Alpn:       opts.Alpn,
+	}
+	fingerprint := p.ClientFingerprint
+	// v3 需要设置 session id，使用 utls
+	if version == 3 && fingerprint == "" {
+		fingerprint = "chrome"
+	}
+	if fingerprint != "" {
+		tls.Utls = &singbox.Utls{Enabled: true, Fingerprint: fingerprint}
+	}
+	return singbox.Outbound{
+		Type: "shadowtls",
+		Tag:  p.Name + "/shadow-tls",
+		Options: &singbox.ShadowTls{
+			ServerOptions: serverOptions(p),
+			Version:       version,
+			Password:      opts.Password,
+			Tls:           tls,
+		},
+	}, nil
+}
+
+// 转换 sing-box 的插件和 SIP003 格式的插件参数为 clash 的插件配置
+func pluginToClash(plugin string, pluginOpts string) (string, *PluginOpts, error) {
+	args := make(map[string]string)
+	for _, item := range strings.Split(pluginOpts, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			key, value, _ := strings.Cut(item, "=")
+			args[key] = value
+		}
+	}
+	switch plugin {
+	case "obfs-local", "simple-obfs":
+		return "obfs", &PluginOpts{Mode: args["obfs"], Host: args["obfs-host"]}, nil
+	case "v2ray-plugin":
+		if mode := args["mode"]; mode != "" && mode != "websocket" {
+			return "", nil, fmt.Errorf("unsupport v2ray-plugin mode '%s'", mode)
+		}
+		_, tls := args["tls"]
+		mux, hasMux := args["mux"]
+		return "v2ray-plugin", &PluginOpts{
+			Mode: "websocket",
+			Host: args["host"],
+			Path: args["path"],
+			Tls:  tls,
+			Mux:  !hasMux || mux != "0",
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupport plugin '%s'", plugin)
+	}
+}
+
+// shadowsocks 通过 shadow-tls 连接时转换为 clash 的 shadow-tls 插件
+func shadowTlsToClash(p *Proxy, st singbox.ShadowTls) {
+	p.Server = st.Server
+	p.Port = st.ServerPort
+	p.Plugin = "shadow-tls"
+	// sing-box 默认使用 v1，clash 默认使用 v2
+	p.PluginOpts = &PluginOpts{Password: st.Password, Version: cmp.Or(st.Version, 1)}
+	if tls := st.Tls; tls != nil {
+		p.PluginOpts.Host = tls.ServerName
+		p.PluginOpts.SkipCertVerify = tls.Insecure
+		p.PluginOpts.Alpn = tls.Alpn
+		if tls.Utls != nil {
+			p.ClientFingerprint = tls.Utls.Fingerprint
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/follow1123/sing-box-ctl/singbox"
+)
+
+// 地区，节点名称包含国旗、关键字或者地区代码时属于这个地区
+type Region struct {
+	Name     string
+	Emoji    string
+	Keywords []string
+	// 地区代码前后不能是字母，例如 HK 能匹配 HK01、香港-HK，不能匹配 HKT
+	Codes []string
+}
+
+var defaultRegions = []Region{
+	{Name: "香港", Emoji: "🇭🇰", Keywords: []string{"香港", "Hong Kong", "HongKong"}, Codes: []string{"HK", "HKG"}},
+	{Name: "台湾", Emoji: "🇹🇼", Keywords: []string{"台湾", "台灣", "台北", "Taiwan"}, Codes: []string{"TW", "TWN"}},
+	{Name: "日本", Emoji: "🇯🇵", Keywords: []string{"日本", "东京", "東京", "大阪", "Japan", "Tokyo"}, Codes: []string{"JP", "JPN"}},
+	{Name: "新加坡", Emoji: "🇸🇬", Keywords: []string{"新加坡", "狮城", "獅城", "Singapore"}, Codes: []string{"SG", "SGP"}},
+	{Name: "美国", Emoji: "🇺🇸", Keywords: []string{"美国", "美國", "洛杉矶", "圣何塞", "硅谷", "United States"}, Codes: []string{"US", "USA"}},
+	{Name: "韩国", Emoji: "🇰🇷", Keywords: []string{"韩国", "韓國", "首尔", "Korea", "Seoul"}, Codes: []string{"KR", "KOR"}},
+	{Name: "英国", Emoji: "🇬🇧", Keywords: []string{"英国", "英國", "伦敦", "United Kingdom", "London"}, Codes: []string{"UK", "GB", "GBR"}},
+	{Name: "德国", Emoji: "🇩🇪", Keywords: []string{"德国", "德國", "法兰克福", "Germany", "Frankfurt"}, Codes: []string{"DE", "DEU"}},
+}
+
+// 内置的地区列表
+func DefaultRegions() []Region {
+	return slices.Clone(defaultRegions)
+}
+
+// 分组的 tag，有国旗时使用国旗作为前缀
+func (r Region) tag() string {
+	if r.Emoji == "" {
+		return r.Name
+	}
+	return r.Emoji + " " + r.Name
+}
+
+func (r Region) compile() (*regexp.Regexp, error) {
+	var alternatives []string
+	if r.Emoji != "" {
+		alternatives = append(alternatives, regexp.QuoteMeta(r.Emoji))
+	}
+	// 关键字不区分大小写，地区代码区分大小写，避免匹配到普通单词
+	for _, k := range r.Keywords {
+		alternatives = append(alternatives, `(?i:`+regexp.QuoteMeta(k)+`)`)
+	}
+	for _, c := range r.Codes {
+		alternatives = append(alternatives, `(?:^|[^A-Za-z])`+regexp.QuoteMeta(c)+`(?:[^A-Za-z]|$)`)
+	}
+	if len(alternatives) == 0 {
+		return nil, fmt.Errorf("region '%s' has no emoji, keywords or codes", r.Name)
+	}
+	re, err := regexp.Compile(strings.Join(alternatives, "|"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid region '%s':\n\t%w", r.Name, err)
+	}
+	return re, nil
+}
+
+// 根据节点名称生成地区分组，每个地区生成一个 urltest 和包含它的 selector，没有节点的地区不生成
+// 分组的 tag 和已有的节点、分组重复时添加数字后缀
+func buildRegionGroups(sbc *SingBoxConfig, regions []Region) ([]singbox.Outbound, error) {
+	nodeTags := sbc.NodeTags()
+	var groups []singbox.Outbound
+	for _, r := range regions {
+		re, err := r.compile()
+		if err != nil {
+			return nil, err
+		}
+		nodes := slices.DeleteFunc(slices.Clone(nodeTags), func(tag string) bool {
+			return !re.MatchString(tag)
+		})
+		if len(nodes) == 0 {
+			continue
+		}
+		tag := sbc.tags.unique(r.tag())
+		groups = append(groups, nodeGroups(tag, sbc.tags.unique(tag+"-"+tagAuto), nodes)...)
+	}
+	return groups, nil
+}
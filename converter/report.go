@@ -0,0 +1,203 @@
+package converter
+
+import (
+	"errors"
+	"slices"
+)
+
+// 规则类型不支持，和规则内容错误区分
+var errUnsupportCondition = errors.New("unsupport condition name")
+
+// 转换报告，记录转换和跳过的节点、规则以及生成的规则集
+type Report struct {
+	Proxies  ProxyReport     `json:"proxies"`
+	Groups   GroupReport     `json:"groups"`
+	Rules    RuleReport      `json:"rules"`
+	RuleSets []RuleSetReport `json:"rule_sets"`
+	Settings SettingsReport  `json:"settings"`
+}
+
+type ProxyReport struct {
+	Converted []string `json:"converted"`
+	// 按原因分组的节点名称
+	Skipped map[string][]string `json:"skipped"`
+	// 名称重复添加后缀的节点和分组
+	Renamed []RenamedProxy `json:"renamed"`
+}
+
+type GroupReport struct {
+	// 按原因分组的分组名称
+	Skipped map[string][]string `json:"skipped"`
+}
+
+type RenamedProxy struct {
+	Name string `json:"name"`
+	Tag  string `json:"tag"`
+}
+
+// 按规则类型分组
+type RuleReport struct {
+	Converted   map[string][]string      `json:"converted"`
+	Ignored     map[string][]SkippedRule `json:"ignored"`
+	Unsupported map[string][]SkippedRule `json:"unsupported"`
+}
+
+type SkippedRule struct {
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// 开启 clash_settings 时使用的订阅中的设置
+type SettingsReport struct {
+	// 使用的设置名称，例如 port、allow-lan、dns
+	Converted []string `json:"converted"`
+	// 按设置名称分组的忽略的内容
+	Ignored map[string][]SkippedSetting `json:"ignored"`
+}
+
+type SkippedSetting struct {
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+type RuleSetReport struct {
+	Tag  string `json:"tag"`
+	Type string `json:"type"`
+	// 远程规则集的地址
+	Url string `json:"url,omitempty"`
+	// 内联规则集的规则数量
+	Rules int `json:"rules,omitempty"`
+}
+
+func newReport() *Report {
+	return &Report{
+		Proxies: ProxyReport{
+			Converted: make([]string, 0),
+			Skipped:   make(map[string][]string),
+			Renamed:   make([]RenamedProxy, 0),
+		},
+		Groups: GroupReport{
+			Skipped: make(map[string][]string),
+		},
+		Rules: RuleReport{
+			Converted:   make(map[string][]string),
+			Ignored:     make(map[string][]SkippedRule),
+			Unsupported: make(map[string][]SkippedRule),
+		},
+		RuleSets: make([]RuleSetReport, 0),
+		Settings: SettingsReport{
+			Converted: make([]string, 0),
+			Ignored:   make(map[string][]SkippedSetting),
+		},
+	}
+}
+
+func (r *Report) addProxy(name string) {
+	r.Proxies.Converted = append(r.Proxies.Converted, name)
+}
+
+// 已经转换的节点被删除时从转换成功的列表中移除
+func (r *Report) skipProxy(name string, reason error) {
+	r.Proxies.Converted = slices.DeleteFunc(r.Proxies.Converted, func(converted string) bool {
+		return converted == name
+	})
+	r.Proxies.Skipped[reason.Error()] = append(r.Proxies.Skipped[reason.Error()], name)
+}
+
+func (r *Report) renameProxy(name string, tag string) {
+	r.Proxies.Renamed = append(r.Proxies.Renamed, RenamedProxy{Name: name, Tag: tag})
+}
+
+func (r *Report) skipGroup(name string, reason error) {
+	r.Groups.Skipped[reason.Error()] = append(r.Groups.Skipped[reason.Error()], name)
+}
+
+// 合并其他报告中的节点
+func (r *Report) mergeProxies(other *Report) {
+	r.Proxies.Converted = append(r.Proxies.Converted, other.Proxies.Converted...)
+	r.Proxies.Renamed = append(r.Proxies.Renamed, other.Proxies.Renamed...)
+	for reason, names := range other.Proxies.Skipped {
+		r.Proxies.Skipped[reason] = append(r.Proxies.Skipped[reason], names...)
+	}
+	for reason, names := range other.Groups.Skipped {
+		r.Groups.Skipped[reason] = append(r.Groups.Skipped[reason], names...)
+	}
+}
+
+// err 为空时是转换成功的规则
+func (r *Report) addRule(typ string, rule string, err error) {
+	switch {
+	case err == nil:
+		r.Rules.Converted[typ] = append(r.Rules.Converted[typ], rule)
+	case errors.Is(err, errUnsupportCondition):
+		r.Rules.Unsupported[typ] = append(r.Rules.Unsupported[typ], SkippedRule{Rule: rule, Reason: err.Error()})
+	default:
+		r.Rules.Ignored[typ] = append(r.Rules.Ignored[typ], SkippedRule{Rule: rule, Reason: err.Error()})
+	}
+}
+
+func (r *Report) addSetting(name string) {
+	r.Settings.Converted = append(r.Settings.Converted, name)
+}
+
+func (r *Report) ignoreSetting(name string, value string, reason error) {
+	r.Settings.Ignored[name] = append(r.Settings.Ignored[name], SkippedSetting{Value: value, Reason: reason.Error()})
+}
+
+// 记录生成的规则集
+func (r *Report) addRuleSets(sbc *SingBoxConfig) {
+	for _, rs := range sbc.InlineRuleSet {
+		r.RuleSets = append(r.RuleSets, RuleSetReport{Tag: rs.Tag, Type: "inline", Rules: len(rs.Rules)})
+	}
+	for _, rs := range sbc.RemoteRuleSet {
+		r.RuleSets = append(r.RuleSets, RuleSetReport{Tag: rs.Tag, Type: "remote", Url: rs.Url})
+	}
+}
+
+// 订阅中是否设置了入站的端口和是否允许局域网连接
+func (r *Report) ListenSettings() (port bool, allowLAN bool) {
+	return slices.Contains(r.Settings.Converted, settingPort), slices.Contains(r.Settings.Converted, settingAllowLan)
+}
+
+// 忽略的设置数量
+func (r *Report) IgnoredSettings() int {
+	count := 0
+	for _, items := range r.Settings.Ignored {
+		count += len(items)
+	}
+	return count
+}
+
+// 跳过的分组数量
+func (r *Report) SkippedGroups() int {
+	count := 0
+	for _, names := range r.Groups.Skipped {
+		count += len(names)
+	}
+	return count
+}
+
+// 跳过的节点数量
+func (r *Report) SkippedProxies() int {
+	count := 0
+	for _, names := range r.Proxies.Skipped {
+		count += len(names)
+	}
+	return count
+}
+
+// 所有出现过的规则类型，按名称排序
+func (r *Report) RuleTypes() []string {
+	var types []string
+	for typ := range r.Rules.Converted {
+		types = append(types, typ)
+	}
+	for typ := range r.Rules.Ignored {
+		types = append(types, typ)
+	}
+	for typ := range r.Rules.Unsupported {
+		types = append(types, typ)
+	}
+	slices.Sort(types)
+	return slices.Compact(types)
+}
@@ -0,0 +1,314 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/follow1123/sing-box-ctl/singbox"
+)
+
+const geoRuleSetUrl = "https://raw.githubusercontent.com/MetaCubeX/meta-rules-dat/sing/geo/%s/%s.srs"
+
+// 模板内置的远程规则集
+var builtinRuleSets = []string{
+	"geosite-cn",
+	"geosite-openai",
+	"geosite-github",
+	"geosite-microsoft",
+	"geosite-geolocation-!cn",
+	"geoip-cn",
+}
+
+// GEOIP,LAN 对应的局域网地址
+var privateIpCidr = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+// 转换规则，连续的指向同一个 outbound 的规则合并为一个内联规则集
+type ruleConverter struct {
+	sbc           *SingBoxConfig
+	ruleProviders *ruleProviderConverter
+	currentTarget string
+	ruleIdx       int
+	resolved      bool
+}
+
+// 转换规则
+func convertRules(cc *ClashConfig, sbc *SingBoxConfig, o *options) {
+	rc := &ruleConverter{
+		sbc:           sbc,
+		ruleProviders: newRuleProviderConverter(cc, sbc, o),
+	}
+	for _, r := range cc.Rules {
+		typ := strings.ToUpper(splitRule(r)[0])
+		sbc.report.addRule(typ, r, rc.convert(r))
+	}
+}
+
+func (rc *ruleConverter) convert(r string) error {
+	items := splitRule(r)
+	typ := strings.ToUpper(items[0])
+	if typ == "MATCH" {
+		if len(items) < 2 {
+			return errors.New("no target")
+		}
+		rc.final(items[1])
+		return nil
+	}
+	if len(items) < 3 {
+		return errors.New("invalid rule")
+	}
+	payload, target := items[1], items[2]
+	noResolve := slices.Contains(items[3:], "no-resolve")
+	switch typ {
+	case "RULE-SET":
+		tag, err := rc.ruleProviders.convert(payload)
+		if err != nil {
+			return err
+		}
+		if !noResolve && rc.ruleProviders.providers[payload].Behavior == "ipcidr" {
+			rc.resolve()
+		}
+		rc.addRuleSetRule(tag, target)
+		return nil
+	case "GEOSITE":
+		rc.addRuleSetRule(geoRuleSet(rc.sbc, "geosite", payload), target)
+		return nil
+	case "GEOIP":
+		if !isPrivateGeoIp(payload) {
+			if !noResolve {
+				rc.resolve()
+			}
+			rc.addRuleSetRule(geoRuleSet(rc.sbc, "geoip", payload), target)
+			return nil
+		}
+	}
+	hr, err := parseCondition(typ, payload)
+	if err != nil {
+		return err
+	}
+	if !noResolve && (typ == "IP-CIDR" || typ == "IP-CIDR6" || typ == "GEOIP") {
+		rc.resolve()
+	}
+	if target != rc.currentTarget {
+		rc.currentTarget = target
+		rc.ruleIdx++
+		ruleSetName := fmt.Sprintf("providers-builtin-rule-%v", rc.ruleIdx)
+		rc.sbc.Rules = append(rc.sbc.Rules, rc.rule(ruleSetName, target))
+		rc.sbc.InlineRuleSet = append(rc.sbc.InlineRuleSet, InlineRuleSet{
+			Tag: ruleSetName,
+		})
+	}
+	rc.sbc.InlineRuleSet[len(rc.sbc.InlineRuleSet)-1].AddRule(hr)
+	return nil
+}
+
+// 添加直接引用规则集的规则，会打断连续的规则，后面的规则重新分组
+func (rc *ruleConverter) addRuleSetRule(tag string, target string) {
+	rc.currentTarget = ""
+	rc.sbc.Rules = append(rc.sbc.Rules, rc.rule(tag, target))
+}
+
+func (rc *ruleConverter) rule(ruleSet string, target string) Rule {
+	switch target {
+	case "REJECT":
+		return Rule{RuleSet: ruleSet, Action: "reject"}
+	case "REJECT-DROP":
+		return Rule{RuleSet: ruleSet, Action: "reject", Method: "drop"}
+	default:
+		return Rule{RuleSet: ruleSet, Outbound: ruleOutbound(rc.sbc, target)}
+	}
+}
+
+// 没有 no-resolve 的 IP 规则需要先解析域名，只需要解析一次
+func (rc *ruleConverter) resolve() {
+	if rc.resolved {
+		return
+	}
+	rc.resolved = true
+	rc.currentTarget = ""
+	rc.sbc.Rules = append(rc.sbc.Rules, Rule{Action: "resolve"})
+}
+
+// MATCH 规则，指向节点、分组或直连时修改 route.final，指向 REJECT 时在最后添加拒绝规则，
+// 否则使用模板内置的 final
+func (rc *ruleConverter) final(target string) {
+	if target == "REJECT" || target == "REJECT-DROP" {
+		rule := rc.rule("", target)
+		rc.sbc.FinalRule = &rule
+		return
+	}
+	outbound := ruleOutbound(rc.sbc, target)
+	if outbound == tagSelect {
+		return
+	}
+	rc.sbc.Final = outbound
+}
+
+// 添加 geosite、geoip 远程规则集，模板内置的规则集不重复添加
+func geoRuleSet(sbc *SingBoxConfig, kind string, code string) string {
+	code = strings.ToLower(code)
+	tag := kind + "-" + code
+	if slices.Contains(builtinRuleSets, tag) || slices.ContainsFunc(sbc.RemoteRuleSet, func(rs RemoteRuleSet) bool {
+		return rs.Tag == tag
+	}) {
+		return tag
+	}
+	sbc.RemoteRuleSet = append(sbc.RemoteRuleSet, RemoteRuleSet{
+		Tag:    tag,
+		Format: "binary",
+		Url:    fmt.Sprintf(geoRuleSetUrl, kind, code),
+	})
+	return tag
+}
+
+func isPrivateGeoIp(code string) bool {
+	code = strings.ToLower(code)
+	return code == "lan" || code == "private"
+}
+
+// 按逗号分割规则，忽略括号内的逗号
+func splitRule(rule string) []string {
+	var items []string
+	depth := 0
+	start := 0
+	for i, r := range rule {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(rule[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(items, strings.TrimSpace(rule[start:]))
+}
+
+// 转换规则条件，不包含规则指向的 outbound
+func parseCondition(typ string, payload string) (*HeadlessRule, error) {
+	hr := &HeadlessRule{}
+	switch typ {
+	case "AND", "OR", "NOT":
+		// 逻辑规则格式为 AND,((DOMAIN,a.com),(NETWORK,UDP))
+		if !strings.HasPrefix(payload, "(") || !strings.HasSuffix(payload, ")") {
+			return nil, fmt.Errorf("invalid logical payload '%s'", payload)
+		}
+		for _, sub := range splitRule(payload[1 : len(payload)-1]) {
+			if !strings.HasPrefix(sub, "(") || !strings.HasSuffix(sub, ")") {
+				return nil, fmt.Errorf("invalid logical payload '%s'", payload)
+			}
+			items := splitRule(sub[1 : len(sub)-1])
+			if len(items) < 2 {
+				return nil, fmt.Errorf("invalid logical payload '%s'", payload)
+			}
+			subRule, err := parseCondition(strings.ToUpper(items[0]), items[1])
+			if err != nil {
+				return nil, err
+			}
+			hr.Rules = append(hr.Rules, subRule)
+		}
+		hr.Mode = strings.ToLower(typ)
+		if typ == "NOT" {
+			if len(hr.Rules) != 1 {
+				return nil, errors.New("NOT rule must have only one condition")
+			}
+			hr.Mode = "and"
+			hr.Invert = true
+		}
+	case "GEOIP":
+		if !isPrivateGeoIp(payload) {
+			return nil, errors.New("GEOIP can not be used in logical rule")
+		}
+		for _, cidr := range privateIpCidr {
+			hr.AddCondition("ip_cidr", cidr)
+		}
+	case "DST-PORT":
+		if err := addPortConditions(hr, payload, "port", "port_range"); err != nil {
+			return nil, err
+		}
+	case "SRC-PORT":
+		if err := addPortConditions(hr, payload, "source_port", "source_port_range"); err != nil {
+			return nil, err
+		}
+	case "NETWORK":
+		hr.AddCondition("network", strings.ToLower(payload))
+	default:
+		name := ruleType(typ)
+		if name == "" {
+			return nil, fmt.Errorf("%w: %v", errUnsupportCondition, typ)
+		}
+		hr.AddCondition(name, payload)
+	}
+	return hr, nil
+}
+
+// 转换端口，clash 格式为 80/443/1000-2000
+func addPortConditions(hr *HeadlessRule, payload string, portName string, rangeName string) error {
+	for _, port := range strings.Split(payload, "/") {
+		start, end, isRange := strings.Cut(port, "-")
+		if _, err := strconv.ParseUint(start, 10, 16); err != nil {
+			return fmt.Errorf("invalid port '%s'", payload)
+		}
+		if !isRange {
+			hr.AddCondition(portName, start)
+			continue
+		}
+		if _, err := strconv.ParseUint(end, 10, 16); err != nil {
+			return fmt.Errorf("invalid port '%s'", payload)
+		}
+		hr.AddCondition(rangeName, start+":"+end)
+	}
+	return nil
+}
+
+// 规则指向节点或分组时直接使用，否则根据名称判断是直连还是代理
+func ruleOutbound(sbc *SingBoxConfig, outbound string) string {
+	if slices.Contains(sbc.NodeTags(), outbound) || slices.ContainsFunc(sbc.Groups, func(g singbox.Outbound) bool {
+		return g.Tag == outbound
+	}) {
+		return outbound
+	}
+	if strings.Contains(outbound, "直连") || strings.Contains(strings.ToLower(outbound), "direct") {
+		return tagDirect
+	}
+	return tagSelect
+}
+
+func ruleType(clashRule string) string {
+	switch clashRule {
+	case "DOMAIN":
+		return "domain"
+	case "DOMAIN-SUFFIX":
+		return "domain_suffix"
+	case "DOMAIN-KEYWORD":
+		return "domain_keyword"
+	case "DOMAIN-REGEX":
+		return "domain_regex"
+	case "IP-CIDR", "IP-CIDR6":
+		return "ip_cidr"
+	case "SRC-IP-CIDR":
+		return "source_ip_cidr"
+	case "PROCESS-NAME":
+		return "process_name"
+	case "PROCESS-PATH":
+		return "process_path"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,187 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// MetaCubeX/meta-rules-dat 的 meta 分支和 sing 分支规则名称一一对应
+var metaRulesPattern = regexp.MustCompile(`MetaCubeX/meta-rules-dat(?:/raw)?[/@]meta/(geo(?:-lite)?/(?:geosite|geoip))/([^/]+)\.(?:mrs|yaml|list|txt)$`)
+
+const metaRulesSingUrl = "https://raw.githubusercontent.com/MetaCubeX/meta-rules-dat/sing/%s/%s.srs"
+
+// 转换 RULE-SET 规则引用的 rule-provider，同一个 rule-provider 只转换一次
+type ruleProviderConverter struct {
+	providers map[string]RuleProvider
+	sbc       *SingBoxConfig
+	loader    func(source string) ([]byte, error)
+	converted map[string]string
+}
+
+func newRuleProviderConverter(cc *ClashConfig, sbc *SingBoxConfig, o *options) *ruleProviderConverter {
+	return &ruleProviderConverter{
+		providers: cc.RuleProviders,
+		sbc:       sbc,
+		loader:    o.loader,
+		converted: make(map[string]string),
+	}
+}
+
+// 转换 rule-provider 并返回规则集的 tag，能使用远程规则集时优先使用远程规则集，否则加载内容转换为内联规则集
+func (rc *ruleProviderConverter) convert(name string) (string, error) {
+	if tag, ok := rc.converted[name]; ok {
+		return tag, nil
+	}
+	rp, ok := rc.providers[name]
+	if !ok {
+		return "", fmt.Errorf("rule provider '%s' not exists", name)
+	}
+	tag := "providers-rule-set-" + name
+	if ruleSetUrl, ok := remoteRuleSetUrl(rp); ok {
+		ruleSet := RemoteRuleSet{
+			Tag:    tag,
+			Format: "binary",
+			Url:    ruleSetUrl,
+		}
+		if rp.Interval > 0 {
+			ruleSet.UpdateInterval = fmt.Sprintf("%ds", rp.Interval)
+		}
+		rc.sbc.RemoteRuleSet = append(rc.sbc.RemoteRuleSet, ruleSet)
+	} else {
+		payload, err := rc.payload(rp)
+		if err != nil {
+			return "", fmt.Errorf("load rule provider '%s' error: %w", name, err)
+		}
+		ruleSet, err := payloadToRuleSet(tag, rp.Behavior, payload, rc.ignoreRule(name))
+		if err != nil {
+			return "", fmt.Errorf("convert rule provider '%s' error: %w", name, err)
+		}
+		rc.sbc.InlineRuleSet = append(rc.sbc.InlineRuleSet, *ruleSet)
+	}
+	rc.converted[name] = tag
+	return tag, nil
+}
+
+// 返回记录 rule-provider 中无法转换的规则的函数，和 rules 中的规则一样记录到转换报告
+func (rc *ruleProviderConverter) ignoreRule(name string) func(rule string, err error) {
+	return func(rule string, err error) {
+		typ := strings.ToUpper(splitRule(rule)[0])
+		rc.sbc.report.addRule(typ, rule, fmt.Errorf("rule provider '%s': %w", name, err))
+	}
+}
+
+// 获取 rule-provider 的规则列表
+func (rc *ruleProviderConverter) payload(rp RuleProvider) ([]string, error) {
+	var source string
+	switch rp.Type {
+	case "inline":
+		return rp.Payload, nil
+	case "file":
+		source = rp.Path
+	case "http":
+		source = rp.Url
+	default:
+		return nil, fmt.Errorf("unsupport type '%s'", rp.Type)
+	}
+	if rp.Format == "mrs" {
+		return nil, errors.New("unsupport mrs format")
+	}
+	if rc.loader == nil {
+		return nil, fmt.Errorf("can not load '%s'", source)
+	}
+	data, err := rc.loader(source)
+	if err != nil {
+		return nil, err
+	}
+	if rp.Format == "text" {
+		var payload []string
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			payload = append(payload, line)
+		}
+		return payload, scanner.Err()
+	}
+	var content struct {
+		Payload []string `yaml:"payload"`
+	}
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("unmarshal payload error: %w", err)
+	}
+	return content.Payload, nil
+}
+
+// http 类型的 rule-provider 是 sing-box 规则集或者能对应到 sing-box 规则集时返回远程规则集地址
+func remoteRuleSetUrl(rp RuleProvider) (string, bool) {
+	if rp.Type != "http" {
+		return "", false
+	}
+	u, err := url.Parse(rp.Url)
+	if err != nil {
+		return "", false
+	}
+	if strings.HasSuffix(u.Path, ".srs") {
+		return rp.Url, true
+	}
+	matches := metaRulesPattern.FindStringSubmatch(u.Host + u.Path)
+	if matches == nil {
+		return "", false
+	}
+	return fmt.Sprintf(metaRulesSingUrl, matches[1], matches[2]), true
+}
+
+// 按 behavior 转换规则列表，classical 中无法转换的规则通过 ignore 记录
+func payloadToRuleSet(tag string, behavior string, payload []string, ignore func(rule string, err error)) (*InlineRuleSet, error) {
+	ruleSet := &InlineRuleSet{Tag: tag}
+	for _, item := range payload {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		hr := &HeadlessRule{}
+		switch behavior {
+		case "domain":
+			switch {
+			case strings.HasPrefix(item, "+."):
+				hr.AddCondition("domain_suffix", item[2:])
+			case strings.HasPrefix(item, "."):
+				hr.AddCondition("domain_suffix", item)
+			case strings.HasPrefix(item, "*."):
+				hr.AddCondition("domain_regex", `^[^.]+`+regexp.QuoteMeta(item[1:])+`$`)
+			default:
+				hr.AddCondition("domain", item)
+			}
+		case "ipcidr":
+			hr.AddCondition("ip_cidr", item)
+		case "classical":
+			items := splitRule(item)
+			if len(items) < 2 {
+				ignore(item, errors.New("invalid rule"))
+				continue
+			}
+			var err error
+			hr, err = parseCondition(strings.ToUpper(items[0]), items[1])
+			if err != nil {
+				ignore(item, err)
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("unsupport behavior '%s'", behavior)
+		}
+		ruleSet.AddRule(hr)
+	}
+	if len(ruleSet.Rules) == 0 {
+		return nil, errors.New("no supported rules")
+	}
+	return ruleSet, nil
+}
@@ -0,0 +1,386 @@
+package converter
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/follow1123/sing-box-ctl/singbox"
+	"github.com/goccy/go-yaml"
+)
+
+// 转换订阅中的 dns 设置生成的 dns 服务器
+const (
+	dnsLocal    = "dns-local"
+	dnsRemote   = "dns-remote"
+	dnsResolver = "dns-resolver"
+	dnsHosts    = "dns-hosts"
+	dnsFakeIp   = "dns-fakeip"
+)
+
+// 报告中记录的设置名称
+const (
+	settingPort     = "port"
+	settingAllowLan = "allow-lan"
+	settingDns      = "dns"
+	settingHosts    = "hosts"
+	settingSniffer  = "sniffer"
+)
+
+// 生成配置使用的 dns、入站和嗅探设置
+type Settings struct {
+	DNSServers []singbox.DNSServer
+	// hosts、nameserver-policy 和 fake-ip 的规则，优先于按路由规则分流的 dns 规则
+	DNSRules []singbox.DNSRule
+	// 直连的域名使用 LocalDns，其他域名使用 RemoteDns
+	LocalDns     string
+	RemoteDns    string
+	Strategy     string
+	ClientSubnet string
+	Inbound      singbox.Mixed
+	// 至少包含嗅探 dns 的规则
+	Sniff []singbox.Rule
+}
+
+// 模板内置的设置
+func defaultSettings() *Settings {
+	return &Settings{
+		DNSServers: []singbox.DNSServer{
+			{Type: "udp", Tag: dnsGoogleUdp, Server: "8.8.8.8", Detour: tagSelect},
+			{Type: "https", Tag: dnsGoogle, Server: "dns.google", DomainResolver: dnsGoogleUdp, Detour: tagSelect},
+			{Type: "https", Tag: dnsAli, Server: "dns.alidns.com", DomainResolver: dns114},
+			{Type: "udp", Tag: dns114, Server: "114.114.114.114"},
+		},
+		LocalDns:     dnsAli,
+		RemoteDns:    dnsGoogle,
+		Strategy:     "ipv4_only",
+		ClientSubnet: "114.114.114.114/24",
+		Inbound:      singbox.Mixed{Listen: "::", ListenPort: 7899},
+		Sniff:        []singbox.Rule{{Action: "sniff"}},
+	}
+}
+
+// 转换订阅中的 dns、hosts、入站和嗅探设置，订阅中没有的设置使用内置的设置
+// 使用的和忽略的设置记录到报告中
+func convertSettings(cc *ClashConfig, sbc *SingBoxConfig) {
+	s := defaultSettings()
+	report := sbc.report
+	// mixed 入站同时支持 http 和 socks
+	if port := cmp.Or(cc.MixedPort, cc.Port, cc.SocksPort); port != 0 {
+		s.Inbound.ListenPort = port
+		report.addSetting(settingPort)
+	}
+	if cc.AllowLan != nil {
+		s.Inbound.Listen = listenAddress(*cc.AllowLan, cc.BindAddress)
+		report.addSetting(settingAllowLan)
+	}
+	if cc.Dns != nil && cc.Dns.Enable {
+		if err := convertDns(cc.Dns, s, sbc); err != nil {
+			report.ignoreSetting(settingDns, "", err)
+		} else {
+			report.addSetting(settingDns)
+		}
+	}
+	if convertHosts(cc.Hosts, s, report) {
+		report.addSetting(settingHosts)
+	}
+	if cc.Sniffer != nil {
+		s.Sniff = convertSniffer(cc.Sniffer, report)
+		report.addSetting(settingSniffer)
+	}
+	sbc.Settings = s
+}
+
+// 不允许局域网连接时只监听本地地址，bind-address 为 * 时监听所有地址
+func listenAddress(allowLan bool, bindAddress string) string {
+	if !allowLan {
+		return "127.0.0.1"
+	}
+	if bindAddress == "" || bindAddress == "*" {
+		return "::"
+	}
+	return bindAddress
+}
+
+// nameserver 和 fallback 的第一个服务器分别作为直连和代理域名的 dns 服务器，没有 fallback 时都使用 nameserver
+// default-nameserver 的第一个服务器用于解析其他 dns 服务器的域名
+func convertDns(d *ClashDns, s *Settings, sbc *SingBoxConfig) error {
+	if len(d.Nameserver) == 0 {
+		return errors.New("no nameserver")
+	}
+	resolver := singbox.DNSServer{Type: "udp", Tag: dnsResolver, Server: "114.114.114.114"}
+	if len(d.DefaultNameserver) > 0 {
+		var err error
+		resolver, err = parseNameserver(dnsResolver, d.DefaultNameserver[0])
+		if err != nil {
+			return err
+		}
+		if resolver.DomainResolver != "" {
+			return fmt.Errorf("default nameserver '%s' is not an ip address", d.DefaultNameserver[0])
+		}
+	}
+	local, err := parseNameserver(dnsLocal, d.Nameserver[0])
+	if err != nil {
+		return err
+	}
+	servers := []singbox.DNSServer{local}
+	remoteDns := dnsLocal
+	// 已经添加的服务器，nameserver-policy 使用相同的服务器时不重复添加
+	known := map[string]string{d.Nameserver[0]: dnsLocal}
+	if len(d.Fallback) > 0 {
+		remote, err := parseNameserver(dnsRemote, d.Fallback[0])
+		if err != nil {
+			return err
+		}
+		servers = append(servers, remote)
+		remoteDns = dnsRemote
+		known[d.Fallback[0]] = dnsRemote
+	}
+	for _, servers := range [][]string{d.DefaultNameserver, d.Nameserver, d.Fallback} {
+		for _, server := range servers[min(len(servers), 1):] {
+			sbc.report.ignoreSetting(settingDns, server, errors.New("only the first server is used"))
+		}
+	}
+
+	var rules []singbox.DNSRule
+	policies := 0
+	for _, item := range d.NameserverPolicy {
+		key := fmt.Sprint(item.Key)
+		address, err := policyNameserver(item.Value)
+		if err != nil {
+			sbc.report.ignoreSetting("nameserver-policy", key, err)
+			continue
+		}
+		tag, ok := known[address]
+		var server singbox.DNSServer
+		if !ok {
+			tag = fmt.Sprintf("dns-policy-%d", policies+1)
+			server, err = parseNameserver(tag, address)
+			if err != nil {
+				sbc.report.ignoreSetting("nameserver-policy", key, err)
+				continue
+			}
+		}
+		rule, converted := domainRule(sbc, "nameserver-policy", strings.Split(key, ","))
+		if !converted {
+			continue
+		}
+		if !ok {
+			servers = append(servers, server)
+			known[address] = tag
+			policies++
+		}
+		rule.Server = tag
+		rules = append(rules, rule)
+	}
+
+	if d.EnhancedMode == "fake-ip" {
+		fakeIp := singbox.DNSServer{Type: "fakeip", Tag: dnsFakeIp, Inet4Range: cmp.Or(d.FakeIpRange, "198.18.0.1/16")}
+		queryType := singbox.Listable[string]{"A"}
+		if d.Ipv6 {
+			fakeIp.Inet6Range = "fc00::/18"
+			queryType = append(queryType, "AAAA")
+		}
+		servers = append(servers, fakeIp)
+		// fake-ip-filter 中的域名返回真实的地址
+		if rule, ok := domainRule(sbc, "fake-ip-filter", d.FakeIpFilter); ok {
+			rule.Server = dnsLocal
+			rules = append(rules, rule)
+		}
+		rules = append(rules, singbox.DNSRule{QueryType: queryType, Server: dnsFakeIp})
+	}
+
+	s.DNSServers = append(servers, resolver)
+	s.DNSRules = append(s.DNSRules, rules...)
+	s.LocalDns = dnsLocal
+	s.RemoteDns = remoteDns
+	s.Strategy = "ipv4_only"
+	if d.Ipv6 {
+		s.Strategy = "prefer_ipv4"
+	}
+	s.ClientSubnet = ""
+	return nil
+}
+
+// 转换 clash 格式的 dns 服务器地址，没有协议时为 udp
+// # 后面是服务器使用的出站，不为 DIRECT 时通过节点选择连接
+func parseNameserver(tag string, nameserver string) (singbox.DNSServer, error) {
+	address, outbound, _ := strings.Cut(nameserver, "#")
+	if address == "system" || address == "system://" {
+		return singbox.DNSServer{Type: "local", Tag: tag}, nil
+	}
+	if !strings.Contains(address, "://") {
+		address = "udp://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil || u.Hostname() == "" {
+		return singbox.DNSServer{}, fmt.Errorf("invalid nameserver '%s'", nameserver)
+	}
+	server := singbox.DNSServer{Tag: tag, Server: u.Hostname()}
+	switch u.Scheme {
+	case "udp", "tcp", "tls", "quic":
+		server.Type = u.Scheme
+	case "https":
+		server.Type = u.Scheme
+		if u.Path != "" && u.Path != "/dns-query" {
+			server.Path = u.Path
+		}
+	case "dhcp":
+		server = singbox.DNSServer{Type: "dhcp", Tag: tag}
+		if u.Host != "system" {
+			server.Interface = u.Host
+		}
+		return server, nil
+	default:
+		return singbox.DNSServer{}, fmt.Errorf("unsupport nameserver '%s'", nameserver)
+	}
+	if port := u.Port(); port != "" {
+		server.ServerPort, err = strconv.Atoi(port)
+		if err != nil {
+			return singbox.DNSServer{}, fmt.Errorf("invalid nameserver '%s'", nameserver)
+		}
+	}
+	if net.ParseIP(server.Server) == nil {
+		server.DomainResolver = dnsResolver
+	}
+	if outbound != "" && !strings.EqualFold(outbound, "DIRECT") {
+		server.Detour = tagSelect
+	}
+	return server, nil
+}
+
+// nameserver-policy 的值为服务器或服务器列表，列表只使用第一个服务器
+func policyNameserver(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []any:
+		if len(v) > 0 {
+			return fmt.Sprint(v[0]), nil
+		}
+	}
+	return "", fmt.Errorf("invalid nameserver '%v'", value)
+}
+
+// 转换 nameserver-policy 和 fake-ip-filter 中的域名，geosite: 转换为远程规则集
+// 没有可以转换的域名时返回 false，setting 为报告中记录忽略的域名使用的设置名称
+func domainRule(sbc *SingBoxConfig, setting string, patterns []string) (singbox.DNSRule, bool) {
+	var rule singbox.DNSRule
+	converted := false
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		switch {
+		case strings.HasPrefix(p, "geosite:"):
+			rule.RuleSet = append(rule.RuleSet, geoRuleSet(sbc, "geosite", strings.TrimPrefix(p, "geosite:")))
+		case strings.HasPrefix(p, "+."):
+			rule.DomainSuffix = append(rule.DomainSuffix, p[2:])
+		case strings.HasPrefix(p, "*."):
+			// * 只匹配一级子域名
+			rule.DomainRegex = append(rule.DomainRegex, `^[^.]+\.`+regexp.QuoteMeta(p[2:])+`$`)
+		case strings.HasPrefix(p, "."):
+			rule.DomainSuffix = append(rule.DomainSuffix, p)
+		case p == "" || strings.ContainsAny(p, "*+:"):
+			sbc.report.ignoreSetting(setting, p, errors.New("unsupport domain pattern"))
+			continue
+		default:
+			rule.Domain = append(rule.Domain, p)
+		}
+		converted = true
+	}
+	return rule, converted
+}
+
+// hosts 转换为 hosts 类型的 dns 服务器，只支持完整的域名和 ip 地址
+// 没有可以转换的 host 时返回 false
+func convertHosts(hosts yaml.MapSlice, s *Settings, report *Report) bool {
+	predefined := make(map[string]singbox.Listable[string])
+	var domains singbox.Listable[string]
+	for _, item := range hosts {
+		domain := fmt.Sprint(item.Key)
+		if strings.ContainsAny(domain, "*+") {
+			report.ignoreSetting(settingHosts, domain, errors.New("wildcard domain is not supported"))
+			continue
+		}
+		addresses, err := hostAddresses(item.Value)
+		if err != nil {
+			report.ignoreSetting(settingHosts, domain, err)
+			continue
+		}
+		predefined[domain] = addresses
+		domains = append(domains, domain)
+	}
+	if len(domains) == 0 {
+		return false
+	}
+	s.DNSServers = append(s.DNSServers, singbox.DNSServer{Type: "hosts", Tag: dnsHosts, Predefined: predefined})
+	// hosts 优先于其他 dns 规则
+	s.DNSRules = append([]singbox.DNSRule{{Domain: domains, Server: dnsHosts}}, s.DNSRules...)
+	return true
+}
+
+func hostAddresses(value any) (singbox.Listable[string], error) {
+	var addresses singbox.Listable[string]
+	switch v := value.(type) {
+	case string:
+		addresses = append(addresses, v)
+	case []any:
+		for _, item := range v {
+			addresses = append(addresses, fmt.Sprint(item))
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("invalid address '%v'", value)
+	}
+	for _, address := range addresses {
+		if net.ParseIP(address) == nil {
+			return nil, fmt.Errorf("'%s' is not an ip address", address)
+		}
+	}
+	return addresses, nil
+}
+
+// 每个嗅探协议生成一条 sniff 规则，没有设置端口时嗅探所有端口
+// 内置的 hijack-dns 规则需要嗅探出 dns 协议才能劫持非 53 端口的 dns 请求，所以始终嗅探 dns
+func convertSniffer(sniffer *Sniffer, report *Report) []singbox.Rule {
+	sniffDns := singbox.Rule{Action: "sniff", Sniffer: singbox.Listable[string]{"dns"}}
+	if !sniffer.Enable {
+		return []singbox.Rule{sniffDns}
+	}
+	var rules []singbox.Rule
+	for _, protocol := range []string{"HTTP", "TLS", "QUIC"} {
+		for name, sp := range sniffer.Sniff {
+			if !strings.EqualFold(name, protocol) {
+				continue
+			}
+			rule := singbox.Rule{Action: "sniff", Sniffer: singbox.Listable[string]{strings.ToLower(protocol)}}
+			for _, port := range sp.Ports {
+				value := fmt.Sprint(port)
+				start, end, isRange := strings.Cut(value, "-")
+				if isRange && isPort(start) && isPort(end) {
+					rule.PortRange = append(rule.PortRange, start+":"+end)
+				} else if !isRange && isPort(value) {
+					n, _ := strconv.Atoi(value)
+					rule.Port = append(rule.Port, uint16(n))
+				} else {
+					report.ignoreSetting(settingSniffer, protocol+" "+value, errors.New("invalid port"))
+				}
+			}
+			rules = append(rules, rule)
+		}
+	}
+	// 没有设置嗅探协议时嗅探所有协议
+	if len(rules) == 0 {
+		return []singbox.Rule{{Action: "sniff"}}
+	}
+	return append(rules, sniffDns)
+}
+
+func isPort(value string) bool {
+	_, err := strconv.ParseUint(value, 10, 16)
+	return err == nil
+}
@@ -0,0 +1,361 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var shareLinkPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// 解析 V2RayN 格式的订阅，内容为 base64 编码或未编码的分享链接，每行一个
+func decodeShareLinks(data []byte) ([]string, bool) {
+	if links, ok := splitShareLinks(data); ok {
+		return links, true
+	}
+	decoded, err := decodeBase64(strings.Join(strings.Fields(string(data)), ""))
+	if err != nil {
+		return nil, false
+	}
+	return splitShareLinks(decoded)
+}
+
+// 每一个非空行都是分享链接时返回所有链接
+func splitShareLinks(data []byte) ([]string, bool) {
+	var links []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !shareLinkPattern.MatchString(line) {
+			return nil, false
+		}
+		links = append(links, line)
+	}
+	if scanner.Err() != nil || len(links) == 0 {
+		return nil, false
+	}
+	return links, true
+}
+
+// 将分享链接转换为 clash 节点，复用 clash 节点的转换逻辑
+func shareLinksToClash(links []string, report *Report) *ClashConfig {
+	cc := &ClashConfig{}
+	for _, link := range links {
+		p, err := parseShareLink(link)
+		if err != nil {
+			// url 的解析错误包含完整的链接
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			report.skipProxy(shareLinkName(link), err)
+			continue
+		}
+		cc.Proxies = append(cc.Proxies, *p)
+	}
+	return cc
+}
+
+// 解析失败的链接在报告中的名称，优先使用链接中的节点名称，否则只保留协议和地址，避免泄漏密码等信息
+func shareLinkName(link string) string {
+	scheme, rest, _ := strings.Cut(link, "://")
+	if _, fragment, ok := strings.Cut(rest, "#"); ok && fragment != "" {
+		if name, err := url.PathUnescape(fragment); err == nil {
+			return name
+		}
+		return fragment
+	}
+	// vmess 等链接的主机部分可能是编码后的完整配置，只保留 host:port 格式的地址
+	if u, err := url.Parse(link); err == nil && u.Port() != "" {
+		return scheme + "://" + u.Host
+	}
+	return scheme + "://***"
+}
+
+func parseShareLink(link string) (*Proxy, error) {
+	scheme, _, _ := strings.Cut(link, "://")
+	switch strings.ToLower(scheme) {
+	case "vmess":
+		return parseVmessLink(link)
+	case "ss":
+		return parseSsLink(link)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "trojan":
+		return parseTrojanLink(u)
+	case "vless":
+		return parseVlessLink(u)
+	case "hysteria2", "hy2":
+		return parseHysteria2Link(u)
+	default:
+		return nil, fmt.Errorf("unsupport protocol: %v", u.Scheme)
+	}
+}
+
+// vmess://base64(json)
+func parseVmessLink(link string) (*Proxy, error) {
+	data, err := decodeBase64(strings.TrimPrefix(link[len("vmess://"):], "//"))
+	if err != nil {
+		return nil, err
+	}
+	var info map[string]any
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("unmarshal vmess link error: %w", err)
+	}
+	get := func(key string) string {
+		if v, ok := info[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	port, err := strconv.Atoi(get("port"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid port '%s'", get("port"))
+	}
+	alterId, _ := strconv.Atoi(get("aid"))
+	p := &Proxy{
+		Type:              "vmess",
+		Name:              get("ps"),
+		Server:            get("add"),
+		Port:              port,
+		Uuid:              get("id"),
+		AlterId:           alterId,
+		Cipher:            get("scy"),
+		Udp:               true,
+		Tls:               get("tls") == "tls",
+		ServerName:        get("sni"),
+		ClientFingerprint: get("fp"),
+	}
+	if alpn := get("alpn"); alpn != "" {
+		p.Alpn = strings.Split(alpn, ",")
+	}
+	network := get("net")
+	// tcp 的 http 伪装对应 http 传输层
+	if network == "tcp" && get("type") == "http" {
+		network = "http"
+	}
+	setLinkTransport(p, network, get("host"), get("path"), get("path"))
+	setLinkName(p)
+	return p, nil
+}
+
+// ss://base64(method:password)@server:port#name 或 ss://base64(method:password@server:port)#name
+func parseSsLink(link string) (*Proxy, error) {
+	body := link[len("ss://"):]
+	body, fragment, _ := strings.Cut(body, "#")
+	if !strings.Contains(body, "@") {
+		decoded, err := decodeBase64(strings.TrimSuffix(body, "/"))
+		if err != nil {
+			return nil, err
+		}
+		body = string(decoded)
+	}
+	u, err := url.Parse("ss://" + body)
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil {
+		return nil, errors.New("no user info")
+	}
+	method, password, hasPassword := u.User.Username(), "", false
+	if password, hasPassword = u.User.Password(); !hasPassword {
+		decoded, err := decodeBase64(u.User.Username())
+		if err != nil {
+			return nil, err
+		}
+		method, password, _ = strings.Cut(string(decoded), ":")
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return nil, fmt.Errorf("invalid port '%s'", u.Port())
+	}
+	name, _ := url.PathUnescape(fragment)
+	p := &Proxy{
+		Type:     "ss",
+		Name:     name,
+		Server:   u.Hostname(),
+		Port:     port,
+		Cipher:   method,
+		Password: password,
+	}
+	// 插件参数格式为 obfs-local;obfs=http;obfs-host=xxx
+	if plugin := u.Query().Get("plugin"); plugin != "" {
+		name, opts, _ := strings.Cut(plugin, ";")
+		p.Plugin, p.PluginOpts, err = pluginToClash(name, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	setLinkName(p)
+	return p, nil
+}
+
+// trojan://password@server:port?sni=xxx&type=ws#name
+func parseTrojanLink(u *url.URL) (*Proxy, error) {
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return nil, fmt.Errorf("invalid port '%s'", u.Port())
+	}
+	query := u.Query()
+	p := &Proxy{
+		Type:              "trojan",
+		Name:              u.Fragment,
+		Server:            u.Hostname(),
+		Port:              port,
+		Password:          u.User.Username(),
+		Udp:               true,
+		Sni:               cmp.Or(query.Get("sni"), query.Get("peer")),
+		SkipCertVerify:    isTrue(query.Get("allowInsecure")) || isTrue(query.Get("insecure")),
+		ClientFingerprint: query.Get("fp"),
+	}
+	if alpn := query.Get("alpn"); alpn != "" {
+		p.Alpn = strings.Split(alpn, ",")
+	}
+	setLinkTransport(p, query.Get("type"), query.Get("host"), query.Get("path"), query.Get("serviceName"))
+	setLinkName(p)
+	return p, nil
+}
+
+// vless://uuid@server:port?security=reality&pbk=xxx&sid=xxx&type=grpc#name
+func parseVlessLink(u *url.URL) (*Proxy, error) {
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return nil, fmt.Errorf("invalid port '%s'", u.Port())
+	}
+	query := u.Query()
+	p := &Proxy{
+		Type:              "vless",
+		Name:              u.Fragment,
+		Server:            u.Hostname(),
+		Port:              port,
+		Uuid:              u.User.Username(),
+		Udp:               true,
+		Flow:              query.Get("flow"),
+		PacketEncoding:    query.Get("packetEncoding"),
+		ServerName:        query.Get("sni"),
+		SkipCertVerify:    isTrue(query.Get("allowInsecure")),
+		ClientFingerprint: query.Get("fp"),
+	}
+	if alpn := query.Get("alpn"); alpn != "" {
+		p.Alpn = strings.Split(alpn, ",")
+	}
+	switch query.Get("security") {
+	case "tls":
+		p.Tls = true
+	case "reality":
+		p.Tls = true
+		p.RealityOpts = &RealityOpts{
+			PublicKey: query.Get("pbk"),
+			ShortId:   query.Get("sid"),
+		}
+	}
+	network := query.Get("type")
+	if network == "tcp" && query.Get("headerType") == "http" {
+		network = "http"
+	}
+	setLinkTransport(p, network, query.Get("host"), query.Get("path"), query.Get("serviceName"))
+	setLinkName(p)
+	return p, nil
+}
+
+// hysteria2://password@server:port?sni=xxx&obfs=salamander&obfs-password=xxx#name
+func parseHysteria2Link(u *url.URL) (*Proxy, error) {
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return nil, fmt.Errorf("invalid port '%s'", u.Port())
+	}
+	query := u.Query()
+	password := u.User.Username()
+	if pass, ok := u.User.Password(); ok {
+		password += ":" + pass
+	}
+	p := &Proxy{
+		Type:           "hysteria2",
+		Name:           u.Fragment,
+		Server:         u.Hostname(),
+		Port:           port,
+		Password:       password,
+		Udp:            true,
+		Ports:          query.Get("mport"),
+		Obfs:           query.Get("obfs"),
+		ObfsPassword:   query.Get("obfs-password"),
+		Sni:            query.Get("sni"),
+		SkipCertVerify: isTrue(query.Get("insecure")),
+	}
+	if alpn := query.Get("alpn"); alpn != "" {
+		p.Alpn = strings.Split(alpn, ",")
+	}
+	setLinkName(p)
+	return p, nil
+}
+
+// 设置分享链接中的传输层参数，grpc 的 path 为 serviceName
+func setLinkTransport(p *Proxy, network string, host string, path string, serviceName string) {
+	switch network {
+	case "", "tcp", "none":
+	case "ws", "httpupgrade":
+		p.Network = "ws"
+		p.WsOpts = &WsOpts{
+			Path:             path,
+			V2rayHttpUpgrade: network == "httpupgrade",
+		}
+		if host != "" {
+			p.WsOpts.Headers = map[string]string{"Host": host}
+		}
+	case "grpc":
+		p.Network = "grpc"
+		p.GrpcOpts = &GrpcOpts{GrpcServiceName: serviceName}
+	case "h2", "http":
+		p.Network = "h2"
+		p.H2Opts = &H2Opts{Path: path}
+		if host != "" {
+			p.H2Opts.Host = strings.Split(host, ",")
+		}
+	default:
+		p.Network = network
+	}
+}
+
+// 没有名称时使用地址作为名称
+func setLinkName(p *Proxy) {
+	if p.Name == "" {
+		p.Name = net.JoinHostPort(p.Server, strconv.Itoa(p.Port))
+	}
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	} {
+		if data, err := encoding.DecodeString(s); err == nil {
+			return data, nil
+		}
+	}
+	// 内容可能包含密码，错误信息中不输出
+	return nil, errors.New("invalid base64")
+}
+
+func isTrue(s string) bool {
+	return s == "1" || strings.EqualFold(s, "true")
+}
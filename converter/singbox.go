@@ -1,41 +1,77 @@
 package converter
 
-type SingBoxConfig struct {
-	Outbounds     []Outbound
-	Rules         []Rule
-	InlineRuleSet []InlineRuleSet
-}
+import (
+	"slices"
+	"strconv"
 
-type Outbound struct {
-	Type     string
-	Tag      string
-	Protocol any
-}
+	"github.com/follow1123/sing-box-ctl/singbox"
+)
+
+// 转换订阅得到的节点、分组和规则，生成配置时和内置的配置合并
+type SingBoxConfig struct {
+	Outbounds []singbox.Outbound
+	// relay 分组和 shadow-tls 中间经过的节点，不作为节点添加到分组中
+	RelayHops []singbox.Outbound
+	Endpoints []singbox.Endpoint
+	Groups    []singbox.Outbound
+	// 合并多个订阅时每个订阅的分组
+	ProviderGroups []singbox.Outbound
+	RegionGroups   []singbox.Outbound
+	Rules          []Rule
+	UserRules      []Rule
+	InlineRuleSet  []InlineRuleSet
+	RemoteRuleSet  []RemoteRuleSet
+	Final          string
+	// MATCH 指向 REJECT、REJECT-DROP 时添加在所有规则最后，拒绝剩余的连接
+	FinalRule *Rule
+	// 订阅中的 dns、入站和嗅探设置，为空时使用内置的设置
+	Settings *Settings
 
-type Shadowsocks struct {
-	Server     string
-	ServerPort int
-	Password   string
-	Network    string
-	Method     string
+	report *Report
+	// 分配节点、中间节点和生成的分组的 tag
+	tags *tagAllocator
 }
 
-type Tls struct {
-	Enabled    bool
-	ServerName string
+// 所有节点的 tag，包括 outbound 和 endpoint
+func (sbc *SingBoxConfig) NodeTags() []string {
+	tags := make([]string, 0, len(sbc.Outbounds)+len(sbc.Endpoints))
+	for _, ob := range sbc.Outbounds {
+		tags = append(tags, ob.Tag)
+	}
+	for _, ep := range sbc.Endpoints {
+		tags = append(tags, ep.Tag)
+	}
+	return tags
 }
 
-type Trojan struct {
-	Server     string
-	ServerPort int
-	Password   string
-	Network    string
-	Tls        Tls
+// 已经使用的 outbound tag，包括节点、relay 中间节点、分组和内置的 outbound
+func (sbc *SingBoxConfig) outboundTags() []string {
+	tags := slices.Concat(sbc.NodeTags(), builtinTags)
+	for _, ob := range slices.Concat(sbc.RelayHops, sbc.Groups, sbc.ProviderGroups, sbc.RegionGroups) {
+		tags = append(tags, ob.Tag)
+	}
+	return tags
 }
 
 type Rule struct {
 	RuleSet  string
 	Outbound string
+	// 不为空时使用 action 代替 outbound，reject 或 resolve
+	Action string
+	// reject 的方式，为空时默认为 default
+	Method string
+}
+
+func (r Rule) toSingBox() singbox.Rule {
+	rule := singbox.Rule{
+		Action:   r.Action,
+		Outbound: r.Outbound,
+		Method:   r.Method,
+	}
+	if r.RuleSet != "" {
+		rule.RuleSet = singbox.Listable[string]{r.RuleSet}
+	}
+	return rule
 }
 
 type RuleCondition struct {
@@ -43,8 +79,13 @@ type RuleCondition struct {
 	Value []string
 }
 
+// Mode 为空时是普通规则，否则是逻辑规则
 type HeadlessRule struct {
 	Conditions []RuleCondition
+
+	Mode   string
+	Invert bool
+	Rules  []*HeadlessRule
 }
 
 func (hr *HeadlessRule) AddCondition(name string, value string) {
@@ -60,7 +101,97 @@ func (hr *HeadlessRule) AddCondition(name string, value string) {
 	})
 }
 
+// 规则集内的规则之间是或的关系
 type InlineRuleSet struct {
-	Tag          string
-	HeadlessRule *HeadlessRule
+	Tag   string
+	Rules []*HeadlessRule
+}
+
+func (rs *InlineRuleSet) toSingBox() singbox.RuleSet {
+	ruleSet := singbox.RuleSet{
+		Type: "inline",
+		Tag:  rs.Tag,
+	}
+	for _, r := range rs.Rules {
+		ruleSet.Rules = append(ruleSet.Rules, r.toSingBox())
+	}
+	return ruleSet
+}
+
+// 添加规则，只有一个条件的普通规则合并到相同条件的规则中
+func (rs *InlineRuleSet) AddRule(hr *HeadlessRule) {
+	if hr.Mode == "" && len(hr.Conditions) == 1 {
+		name := hr.Conditions[0].Name
+		for _, r := range rs.Rules {
+			if r.Mode == "" && len(r.Conditions) == 1 && r.Conditions[0].Name == name {
+				r.Conditions[0].Value = append(r.Conditions[0].Value, hr.Conditions[0].Value...)
+				return
+			}
+		}
+	}
+	rs.Rules = append(rs.Rules, hr)
+}
+
+type RemoteRuleSet struct {
+	Tag            string
+	Format         string
+	Url            string
+	UpdateInterval string
+}
+
+// 转换为 sing-box 的规则，条件名称对应 sing-box 规则的字段名
+func (hr *HeadlessRule) toSingBox() singbox.HeadlessRule {
+	if hr.Mode != "" {
+		rule := singbox.HeadlessRule{
+			Type:   "logical",
+			Mode:   hr.Mode,
+			Invert: hr.Invert,
+		}
+		for _, r := range hr.Rules {
+			rule.Rules = append(rule.Rules, r.toSingBox())
+		}
+		return rule
+	}
+	var rule singbox.HeadlessRule
+	for _, c := range hr.Conditions {
+		switch c.Name {
+		case "network":
+			rule.Network = append(rule.Network, c.Value...)
+		case "domain":
+			rule.Domain = append(rule.Domain, c.Value...)
+		case "domain_suffix":
+			rule.DomainSuffix = append(rule.DomainSuffix, c.Value...)
+		case "domain_keyword":
+			rule.DomainKeyword = append(rule.DomainKeyword, c.Value...)
+		case "domain_regex":
+			rule.DomainRegex = append(rule.DomainRegex, c.Value...)
+		case "source_ip_cidr":
+			rule.SourceIpCidr = append(rule.SourceIpCidr, c.Value...)
+		case "ip_cidr":
+			rule.IpCidr = append(rule.IpCidr, c.Value...)
+		case "source_port":
+			rule.SourcePort = append(rule.SourcePort, parsePorts(c.Value)...)
+		case "source_port_range":
+			rule.SourcePortRange = append(rule.SourcePortRange, c.Value...)
+		case "port":
+			rule.Port = append(rule.Port, parsePorts(c.Value)...)
+		case "port_range":
+			rule.PortRange = append(rule.PortRange, c.Value...)
+		case "process_name":
+			rule.ProcessName = append(rule.ProcessName, c.Value...)
+		case "process_path":
+			rule.ProcessPath = append(rule.ProcessPath, c.Value...)
+		}
+	}
+	return rule
+}
+
+// 端口在转换规则时已经校验过
+func parsePorts(values []string) []uint16 {
+	ports := make([]uint16, 0, len(values))
+	for _, v := range values {
+		port, _ := strconv.ParseUint(v, 10, 16)
+		ports = append(ports, uint16(port))
+	}
+	return ports
 }
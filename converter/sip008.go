@@ -0,0 +1,75 @@
+package converter
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// SIP008 格式的订阅，https://shadowsocks.org/doc/sip008.html
+type Sip008Config struct {
+	Version int            `json:"version"`
+	Servers []Sip008Server `json:"servers"`
+}
+
+type Sip008Server struct {
+	Id         string `json:"id"`
+	Remarks    string `json:"remarks"`
+	Server     string `json:"server"`
+	ServerPort int    `json:"server_port"`
+	Password   string `json:"password"`
+	Method     string `json:"method"`
+	Plugin     string `json:"plugin"`
+	PluginOpts string `json:"plugin_opts"`
+}
+
+// 解析 SIP008 格式的订阅，也支持只包含一个服务器的 shadowsocks 配置文件
+func decodeSip008(data []byte) (*Sip008Config, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		return nil, false
+	}
+	sc := &Sip008Config{}
+	if err := json.Unmarshal(data, sc); err != nil {
+		return nil, false
+	}
+	if len(sc.Servers) > 0 {
+		return sc, true
+	}
+	var server Sip008Server
+	if err := json.Unmarshal(data, &server); err != nil || server.Server == "" || server.Method == "" {
+		return nil, false
+	}
+	sc.Servers = append(sc.Servers, server)
+	return sc, true
+}
+
+// SIP008 的服务器转换为 clash 的 ss 节点，和分享链接一样使用 clash 节点的转换
+// 没有 remarks 时使用服务器地址作为名称，插件转换失败的服务器记录到转换报告
+func sip008ToClash(sc *Sip008Config, report *Report) *ClashConfig {
+	cc := &ClashConfig{}
+	for _, s := range sc.Servers {
+		name := s.Remarks
+		if name == "" {
+			name = net.JoinHostPort(s.Server, strconv.Itoa(s.ServerPort))
+		}
+		p := Proxy{
+			Type:     "ss",
+			Name:     name,
+			Server:   s.Server,
+			Port:     s.ServerPort,
+			Cipher:   s.Method,
+			Password: s.Password,
+		}
+		if s.Plugin != "" {
+			var err error
+			p.Plugin, p.PluginOpts, err = pluginToClash(s.Plugin, s.PluginOpts)
+			if err != nil {
+				report.skipProxy(name, err)
+				continue
+			}
+		}
+		cc.Proxies = append(cc.Proxies, p)
+	}
+	return cc
+}
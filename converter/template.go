@@ -0,0 +1,304 @@
+package converter
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/follow1123/sing-box-ctl/singbox"
+)
+
+// 内置模板，静态的部分直接写在模板中，生成的节点、分组和规则逐个输出，可以导出后修改
+//
+//go:embed config.json.tmpl
+var singBoxConfigTemplate string
+
+// 模板使用的数据，嵌入的 Config 为直接输出时的完整配置，其余字段为订阅生成的部分，
+// 内置模板使用这些字段和模板中的内置分组、规则组成配置
+type TemplateData struct {
+	*singbox.Config
+	// 订阅中所有节点的 tag
+	NodeTags []string
+	// 国内和国外 dns 服务器的 tag
+	LocalDns  string
+	RemoteDns string
+	// 订阅中的 dns 规则和规则对应的 dns 规则
+	DNSRules []singbox.DNSRule
+	// 节点、relay 中间节点、订阅中的分组、合并订阅的分组和地区分组
+	Proxies []singbox.Outbound
+	// 合并订阅和地区分组的 selector
+	SelectTags []string
+	// 嗅探规则
+	Sniff []singbox.Rule
+	// 用户规则和订阅中的规则
+	UserRules         []singbox.Rule
+	SubscriptionRules []singbox.Rule
+	// 拒绝剩余连接的规则，可能为空
+	FinalRule *singbox.Rule
+	// 订阅中 MATCH 规则的 outbound，可能为空
+	Final string
+	// 订阅和用户规则生成的规则集，不包括内置的规则集
+	RuleSets []singbox.RuleSet
+}
+
+func newTemplateData(sbc *SingBoxConfig, config *singbox.Config) TemplateData {
+	settings := cmp.Or(sbc.Settings, defaultSettings())
+	data := TemplateData{
+		Config:            config,
+		NodeTags:          sbc.NodeTags(),
+		LocalDns:          settings.LocalDns,
+		RemoteDns:         settings.RemoteDns,
+		DNSRules:          ruleDnsRules(sbc, settings),
+		Proxies:           generatedOutbounds(sbc),
+		SelectTags:        selectorTags(sbc),
+		Sniff:             settings.Sniff,
+		UserRules:         toSingBoxRules(sbc.UserRules),
+		SubscriptionRules: toSingBoxRules(sbc.Rules),
+		RuleSets:          generatedRuleSets(sbc),
+		Final:             sbc.Final,
+	}
+	if sbc.FinalRule != nil {
+		rule := sbc.FinalRule.toSingBox()
+		data.FinalRule = &rule
+	}
+	return data
+}
+
+// 旧版本的模板数据，节点的配置为 Raw 中去掉首尾括号的 json
+type legacyTemplateData struct {
+	Outbounds     []legacyOutbound
+	Endpoints     []legacyOutbound
+	Groups        []legacyGroup
+	Rules         []Rule
+	InlineRuleSet []InlineRuleSet
+	RemoteRuleSet []RemoteRuleSet
+	Final         string
+	NodeTags      []string
+}
+
+type legacyOutbound struct {
+	Type     string
+	Tag      string
+	Protocol any
+	Raw      string
+}
+
+type legacyGroup struct {
+	Type      string
+	Tag       string
+	Outbounds []string
+	Url       string
+	Interval  string
+	Tolerance int
+}
+
+func newLegacyTemplateData(sbc *SingBoxConfig) (*legacyTemplateData, error) {
+	data := &legacyTemplateData{
+		Rules:         slices.Concat(sbc.UserRules, sbc.Rules),
+		InlineRuleSet: sbc.InlineRuleSet,
+		RemoteRuleSet: sbc.RemoteRuleSet,
+		Final:         sbc.Final,
+		NodeTags:      sbc.NodeTags(),
+	}
+	for _, ob := range slices.Concat(sbc.Outbounds, sbc.RelayHops) {
+		lo, err := newLegacyOutbound(ob.Type, ob.Tag, ob.Options)
+		if err != nil {
+			return nil, err
+		}
+		data.Outbounds = append(data.Outbounds, lo)
+	}
+	for _, ep := range sbc.Endpoints {
+		lo, err := newLegacyOutbound(ep.Type, ep.Tag, ep.Options)
+		if err != nil {
+			return nil, err
+		}
+		data.Endpoints = append(data.Endpoints, lo)
+	}
+	for _, g := range slices.Concat(sbc.Groups, sbc.ProviderGroups, sbc.RegionGroups) {
+		group := legacyGroup{Type: g.Type, Tag: g.Tag}
+		switch opts := g.Options.(type) {
+		case *singbox.Selector:
+			group.Outbounds = opts.Outbounds
+		case *singbox.UrlTest:
+			group.Outbounds = opts.Outbounds
+			group.Url = opts.Url
+			group.Interval = opts.Interval
+			group.Tolerance = opts.Tolerance
+		}
+		data.Groups = append(data.Groups, group)
+	}
+	return data, nil
+}
+
+func newLegacyOutbound(typ string, tag string, options any) (legacyOutbound, error) {
+	raw, err := toJson(options)
+	if err != nil {
+		return legacyOutbound{}, fmt.Errorf("marshal '%s' error: \n\t%w", tag, err)
+	}
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "{"), "}")
+	return legacyOutbound{Type: typ, Tag: tag, Protocol: options, Raw: raw}, nil
+}
+
+// 旧版本模板中判断条件的值是否为数字
+func (rc RuleCondition) IsNumeric() bool {
+	return rc.Name == "port" || rc.Name == "source_port"
+}
+
+// 模板中可以使用的函数
+var templateFuncs = template.FuncMap{
+	"nodeFilter":  nodeFilter,
+	"nodeExclude": nodeExclude,
+	"nodeRegex":   nodeRegex,
+	"quote":       quote,
+	"json":        toJson,
+	"join":        strings.Join,
+	"contains":    strings.Contains,
+	"hasPrefix":   strings.HasPrefix,
+	"hasSuffix":   strings.HasSuffix,
+	"lower":       strings.ToLower,
+	"upper":       strings.ToUpper,
+}
+
+// 返回内置模板
+func DefaultTemplate() string {
+	return singBoxConfigTemplate
+}
+
+// 旧版本模板数据中才有的字段
+var oldTemplateFields = []string{"Raw", "Groups", "InlineRuleSet", "RemoteRuleSet", "Conditions", "IsNumeric"}
+
+// 模板是否使用了旧版本的模板数据，旧版本的模板使用旧的数据执行
+func isLegacyTemplate(tmpl *template.Template) bool {
+	var field string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		if field != "" || node == nil {
+			return
+		}
+		var idents []string
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.IfNode:
+			walk(&n.BranchNode)
+		case *parse.RangeNode:
+			walk(&n.BranchNode)
+		case *parse.WithNode:
+			walk(&n.BranchNode)
+		case *parse.BranchNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.ChainNode:
+			walk(n.Node)
+			idents = n.Field
+		case *parse.FieldNode:
+			idents = n.Ident
+		case *parse.VariableNode:
+			idents = n.Ident
+		}
+		for _, ident := range idents {
+			if slices.Contains(oldTemplateFields, ident) {
+				field = ident
+				return
+			}
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+	return field != ""
+}
+
+// 过滤包含任意关键字的节点，多个关键字使用 | 分割
+func nodeFilter(tags []string, keys string) []string {
+	keyArr := strings.Split(keys, "|")
+	result := make([]string, 0)
+	for _, tag := range tags {
+		if containsAny(tag, keyArr) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// 排除包含任意关键字的节点，多个关键字使用 | 分割
+func nodeExclude(tags []string, keys string) []string {
+	keyArr := strings.Split(keys, "|")
+	result := make([]string, 0)
+	for _, tag := range tags {
+		if !containsAny(tag, keyArr) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// 过滤匹配正则表达式的节点
+func nodeRegex(tags []string, pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s'", pattern)
+	}
+	result := make([]string, 0)
+	for _, tag := range tags {
+		if re.MatchString(tag) {
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+func containsAny(s string, keys []string) bool {
+	for _, k := range keys {
+		if strings.Contains(s, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// 转换为 json 字符串，用于可能包含特殊字符的值，例如正则表达式
+func quote(s string) (string, error) {
+	return toJson(s)
+}
+
+// 转换为 json，例如将节点列表转换为 json 数组
+func toJson(v any) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
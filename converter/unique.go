@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+)
+
+// 模板内置的 outbound，订阅中的节点和分组不能使用这些名称
+var builtinTags = []string{tagSelect, tagAuto, tagOpenAi, tagMicrosoft, tagDirect, tagFinal}
+
+// 生成不重复的 tag，和内置的 outbound 或已经使用的 tag 重复时按顺序添加数字后缀
+// 每个配置使用一个，节点、中间节点和生成的分组都从这里分配 tag
+type tagAllocator struct {
+	used   []string
+	report *Report
+}
+
+func newTagAllocator(report *Report) *tagAllocator {
+	return &tagAllocator{used: slices.Clone(builtinTags), report: report}
+}
+
+// 返回不重复的 tag，添加了后缀时记录到转换报告
+func (ta *tagAllocator) unique(tag string) string {
+	result := ta.alloc(tag)
+	if result != tag {
+		ta.report.renameProxy(tag, result)
+	}
+	return result
+}
+
+// 和 unique 相同，但是不记录到转换报告
+func (ta *tagAllocator) alloc(tag string) string {
+	result := tag
+	for i := 2; ta.contains(result); i++ {
+		result = fmt.Sprintf("%s %d", tag, i)
+	}
+	ta.used = append(ta.used, result)
+	return result
+}
+
+func (ta *tagAllocator) contains(tag string) bool {
+	return slices.Contains(ta.used, tag)
+}
+
+// 分组的名称重复时添加数字后缀，需要在 filterProxies 分配节点名称之后调用
+// 和内置 outbound 重复时修改所有引用的名称，和节点、其他分组重复时引用的是第一个同名的节点或分组
+func uniqueGroupNames(cc *ClashConfig, ta *tagAllocator) {
+	renamed := make(map[string]string)
+	rename := func(name string) string {
+		newName := ta.unique(name)
+		if _, ok := renamed[name]; !ok && newName != name && slices.Contains(builtinTags, name) {
+			renamed[name] = newName
+		}
+		return newName
+	}
+	for i := range cc.ProxyGroups {
+		cc.ProxyGroups[i].Name = rename(cc.ProxyGroups[i].Name)
+	}
+	renameReferences(cc, renamed)
+}
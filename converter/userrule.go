@@ -0,0 +1,47 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// 拒绝连接的 outbound 名称
+const userRuleReject = "reject"
+
+// 转换报告中用户规则的类型，和订阅中的规则类型区分
+const userRuleType = "USER-RULE"
+
+// 用户自定义的规则，Type 为 sing-box 规则的字段名，Outbound 为 reject 时拒绝连接
+// ip_cidr 规则不会先解析域名，只匹配直接访问 IP 的连接
+type UserRule struct {
+	Type     string
+	Value    string
+	Outbound string
+}
+
+// 转换用户自定义的规则，连续的指向同一个 outbound 的规则合并为一个内联规则集
+// 需要在生成地区分组之后调用，outbound 不存在的规则会被忽略并记录到转换报告
+func convertUserRules(rules []UserRule, sbc *SingBoxConfig) {
+	outbounds := sbc.outboundTags()
+	var current string
+	for _, r := range rules {
+		if r.Outbound != userRuleReject && !slices.Contains(outbounds, r.Outbound) {
+			sbc.report.addRule(userRuleType, fmt.Sprintf("%s,%s,%s", r.Type, r.Value, r.Outbound), errors.New("outbound not exists"))
+			continue
+		}
+		if r.Outbound != current {
+			current = r.Outbound
+			tag := fmt.Sprintf("user-rule-%d", len(sbc.UserRules)+1)
+			rule := Rule{RuleSet: tag, Outbound: r.Outbound}
+			if r.Outbound == userRuleReject {
+				rule = Rule{RuleSet: tag, Action: "reject"}
+			}
+			sbc.UserRules = append(sbc.UserRules, rule)
+			sbc.InlineRuleSet = append(sbc.InlineRuleSet, InlineRuleSet{Tag: tag})
+		}
+		hr := &HeadlessRule{}
+		hr.AddCondition(r.Type, r.Value)
+		sbc.InlineRuleSet[len(sbc.InlineRuleSet)-1].AddRule(hr)
+	}
+}
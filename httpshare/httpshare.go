@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/follow1123/sing-box-ctl/config"
+	"github.com/follow1123/sing-box-ctl/converter"
 	U "github.com/follow1123/sing-box-ctl/updater"
 	"github.com/olekukonko/tablewriter"
 )
@@ -34,6 +35,9 @@ const (
 	paramAndroid = "android"
 
 	paramFormat = "format"
+
+	// format 参数的值为 clash 时输出 clash 配置
+	formatClash = "clash"
 )
 
 const urlPath = "/config"
@@ -91,7 +95,7 @@ func (h *HttpShare) PrintHelp() error {
 		{paramLinux, "use linux platform config"},
 		{paramAndroid, "use android platform config"},
 
-		{paramFormat, "format config"},
+		{paramFormat, "format config, use 'format=clash' to get clash config"},
 	}
 
 	table.Header(paramsInfo[0])
@@ -207,9 +211,22 @@ func (h *HttpShare) handle(w http.ResponseWriter, r *http.Request) {
 		handleInternalServerError(w, err)
 		return
 	}
+	data := updater.Data()
+	if params.Get(paramFormat) == formatClash {
+		var report *converter.ExportReport
+		data, report, err = converter.ToClash(data)
+		if err != nil {
+			handleInternalServerError(w, err)
+			return
+		}
+		for _, msg := range report.Messages() {
+			log.Print(msg)
+		}
+		w.Header().Set("Content-Type", "text/yaml; charset=utf-8")
+	}
 	// 写出配置
 	w.WriteHeader(http.StatusOK)
-	if _, err = w.Write(updater.Data()); err != nil {
+	if _, err = w.Write(data); err != nil {
 		handleInternalServerError(w, err)
 		return
 	}
@@ -10,6 +10,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/follow1123/sing-box-ctl/jsonhandler"
 )
@@ -81,6 +83,50 @@ func (p *Provider) Update(name string, url string) error {
 	return nil
 }
 
+// 设置 provider 使用的配置模板路径，为空时删除
+func (p *Provider) SetTemplate(name string, template string) error {
+	return p.setField(name, "template", template, template == "")
+}
+
+// 设置 provider 保留的节点名称正则，为空时删除
+func (p *Provider) SetInclude(name string, patterns []string) error {
+	return p.setField(name, "include", patterns, len(patterns) == 0)
+}
+
+// 设置 provider 排除的节点名称正则，为空时删除
+func (p *Provider) SetExclude(name string, patterns []string) error {
+	return p.setField(name, "exclude", patterns, len(patterns) == 0)
+}
+
+// 设置 provider 节点名称的重命名规则，为空时删除
+func (p *Provider) SetRename(name string, rules []Rename) error {
+	return p.setField(name, "rename", rules, len(rules) == 0)
+}
+
+// 设置 provider 是否使用订阅中 clash 配置的 dns、hosts、入站和嗅探设置，关闭时删除
+func (p *Provider) SetClashSettings(name string, enabled bool) error {
+	return p.setField(name, "clash_settings", enabled, !enabled)
+}
+
+// 设置 provider 的字段，empty 为 true 时删除这个字段
+func (p *Provider) setField(name string, key string, value any, empty bool) error {
+	providers, err := p.List()
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(providers, func(d Data) bool {
+		return d.Name == name
+	})
+	if idx < 0 {
+		return fmt.Errorf("provider '%s' not exists", name)
+	}
+	path := fmt.Sprintf("providers.%d.%s", idx, key)
+	if empty {
+		return p.jh.Delete(path)
+	}
+	return p.jh.Set(path, value)
+}
+
 func (p *Provider) Delete(name string) error {
 	providers, err := p.List()
 	if err != nil {
@@ -156,6 +202,22 @@ func (p *Provider) List() ([]Data, error) {
 	return list, nil
 }
 
+// 生成地区分组使用的地区列表，没有配置时返回 false
+func (p *Provider) Regions() ([]Region, bool, error) {
+	result, exists := p.jh.GetResult("regions")
+	if !exists {
+		return nil, false, nil
+	}
+	if !result.IsArray() {
+		return nil, false, errors.New("regions must be array")
+	}
+	var regions []Region
+	if err := json.Unmarshal([]byte(result.Raw), &regions); err != nil {
+		return nil, false, fmt.Errorf("unmarshal regions error:\n\t%w", err)
+	}
+	return regions, true, nil
+}
+
 func (p *Provider) Save() error {
 	if err := p.jh.Format(); err != nil {
 		return err
@@ -180,12 +242,39 @@ func (p *Provider) deleteDefaultProvider() error {
 }
 
 type Data struct {
-	Name string `json:"name"`
-	Url  string `json:"url"`
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	Template string `json:"template,omitempty"`
+
+	// 节点过滤和重命名规则，都是正则表达式
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	Rename  []Rename `json:"rename,omitempty"`
+
+	// 使用订阅中 clash 配置的 dns、hosts、入站和嗅探设置
+	ClashSettings bool `json:"clash_settings,omitempty"`
+}
+
+// 将节点名称中匹配 Pattern 的部分替换为 Replace，Replace 可以使用 $1 引用分组
+type Rename struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+}
+
+// 节点名称包含国旗、关键字或者地区代码时属于这个地区
+type Region struct {
+	Name     string   `json:"name"`
+	Emoji    string   `json:"emoji,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+	Codes    []string `json:"codes,omitempty"`
 }
 
 func DataFromSource(source string) ([]byte, error) {
 	var data bytes.Buffer
+	// Outline 的 SIP008 订阅地址，ssconf:// 对应 https://
+	if strings.HasPrefix(source, "ssconf://") {
+		source = "https://" + strings.TrimPrefix(source, "ssconf://")
+	}
 	if isHTTPURL(source) {
 		resp, err := http.Get(source)
 		if err != nil {
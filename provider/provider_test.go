@@ -192,3 +192,103 @@ func TestList(t *testing.T) {
 	require.Equal(t, "ccc", pds[2].Name)
 	require.Equal(t, "ddd", pds[3].Name)
 }
+
+func TestSetTemplate(t *testing.T) {
+	conf, err := config.New(t.TempDir())
+	require.NoError(t, err)
+	err = os.WriteFile(conf.ConfigPath(), []byte(`{"providers":[{"name": "aaa","url":"http://localhost:8903"}]}`), 0660)
+	require.NoError(t, err)
+	p, err := provider.New(conf.ConfigPath())
+	require.NoError(t, err)
+
+	err = p.SetTemplate("aaa", "/tmp/config.json.tmpl")
+	require.NoError(t, err)
+	data, err := p.Get("aaa")
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/config.json.tmpl", data.Template)
+
+	err = p.SetTemplate("aaa", "")
+	require.NoError(t, err)
+	data, err = p.Get("aaa")
+	require.NoError(t, err)
+	require.Empty(t, data.Template)
+
+	err = p.SetTemplate("bbb", "/tmp/config.json.tmpl")
+	require.ErrorContains(t, err, "provider 'bbb' not exists")
+}
+
+func TestSetNodeFilter(t *testing.T) {
+	conf, err := config.New(t.TempDir())
+	require.NoError(t, err)
+	err = os.WriteFile(conf.ConfigPath(), []byte(`{"providers":[{"name": "aaa","url":"http://localhost:8903"}]}`), 0660)
+	require.NoError(t, err)
+	p, err := provider.New(conf.ConfigPath())
+	require.NoError(t, err)
+
+	require.NoError(t, p.SetInclude("aaa", []string{"香港", "日本"}))
+	require.NoError(t, p.SetExclude("aaa", []string{"剩余流量", "官网"}))
+	require.NoError(t, p.SetRename("aaa", []provider.Rename{{Pattern: `^\[.*?\]\s*`, Replace: ""}}))
+	require.NoError(t, p.Save())
+
+	p, err = provider.New(conf.ConfigPath())
+	require.NoError(t, err)
+	data, err := p.Get("aaa")
+	require.NoError(t, err)
+	require.Equal(t, []string{"香港", "日本"}, data.Include)
+	require.Equal(t, []string{"剩余流量", "官网"}, data.Exclude)
+	require.Equal(t, []provider.Rename{{Pattern: `^\[.*?\]\s*`, Replace: ""}}, data.Rename)
+
+	require.NoError(t, p.SetInclude("aaa", nil))
+	require.NoError(t, p.SetRename("aaa", nil))
+	data, err = p.Get("aaa")
+	require.NoError(t, err)
+	require.Empty(t, data.Include)
+	require.Empty(t, data.Rename)
+	require.Len(t, data.Exclude, 2)
+
+	err = p.SetExclude("bbb", []string{"a"})
+	require.ErrorContains(t, err, "provider 'bbb' not exists")
+}
+
+func TestSetClashSettings(t *testing.T) {
+	conf, err := config.New(t.TempDir())
+	require.NoError(t, err)
+	err = os.WriteFile(conf.ConfigPath(), []byte(`{"providers":[{"name": "aaa","url":"http://localhost:8903"}]}`), 0660)
+	require.NoError(t, err)
+	p, err := provider.New(conf.ConfigPath())
+	require.NoError(t, err)
+
+	require.NoError(t, p.SetClashSettings("aaa", true))
+	data, err := p.Get("aaa")
+	require.NoError(t, err)
+	require.True(t, data.ClashSettings)
+
+	require.NoError(t, p.SetClashSettings("aaa", false))
+	data, err = p.Get("aaa")
+	require.NoError(t, err)
+	require.False(t, data.ClashSettings)
+
+	err = p.SetClashSettings("bbb", true)
+	require.ErrorContains(t, err, "provider 'bbb' not exists")
+}
+
+func TestRegions(t *testing.T) {
+	conf, err := config.New(t.TempDir())
+	require.NoError(t, err)
+	err = os.WriteFile(conf.ConfigPath(), []byte(`{"providers":[{"name": "aaa","url":"http://localhost:8903"}]}`), 0660)
+	require.NoError(t, err)
+	p, err := provider.New(conf.ConfigPath())
+	require.NoError(t, err)
+	_, exists, err := p.Regions()
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	err = os.WriteFile(conf.ConfigPath(), []byte(`{"regions":[{"name": "香港","emoji":"🇭🇰","keywords":["香港"],"codes":["HK"]}]}`), 0660)
+	require.NoError(t, err)
+	p, err = provider.New(conf.ConfigPath())
+	require.NoError(t, err)
+	regions, exists, err := p.Regions()
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, []provider.Region{{Name: "香港", Emoji: "🇭🇰", Keywords: []string{"香港"}, Codes: []string{"HK"}}}, regions)
+}
@@ -0,0 +1,126 @@
+package rule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/follow1123/sing-box-ctl/jsonhandler"
+)
+
+// 支持的规则类型，对应 sing-box 规则的字段名
+var Types = []string{"domain", "domain_suffix", "domain_keyword", "ip_cidr", "process_name"}
+
+// 自定义规则，保存在 sing-box-ctl-config.json 的 rules 字段中
+type Rules struct {
+	path string
+	jh   *jsonhandler.JsonHandler
+}
+
+func New(path string) (*Rules, error) {
+	var jh *jsonhandler.JsonHandler
+	_, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			jh, err = jsonhandler.FromData([]byte("{}"))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, fmt.Errorf("check rule config '%s' error:\n\t%w", path, err)
+		}
+	}
+	if jh == nil {
+		jh, err = jsonhandler.FromFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Rules{
+		path: path,
+		jh:   jh,
+	}, nil
+}
+
+func (r *Rules) Add(rule Rule) error {
+	if err := rule.Check(); err != nil {
+		return err
+	}
+	rules, err := r.List()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(rules, rule) {
+		return fmt.Errorf("duplicate rule '%s'", rule)
+	}
+	return r.jh.Set("rules.-1", rule)
+}
+
+// 按 List 返回的序号删除规则，序号从 1 开始
+func (r *Rules) Delete(idx int) error {
+	rules, err := r.List()
+	if err != nil {
+		return err
+	}
+	if idx < 1 || idx > len(rules) {
+		return fmt.Errorf("rule %d not exists", idx)
+	}
+	return r.jh.Delete(fmt.Sprintf("rules.%d", idx-1))
+}
+
+func (r *Rules) List() ([]Rule, error) {
+	var list []Rule
+	result, exists := r.jh.GetResult("rules")
+	if !exists {
+		return list, nil
+	}
+	if !result.IsArray() {
+		return nil, errors.New("rules must be array")
+	}
+	if err := json.Unmarshal([]byte(result.Raw), &list); err != nil {
+		return nil, fmt.Errorf("unmarshal rules error:\n\t%w", err)
+	}
+	return list, nil
+}
+
+func (r *Rules) Save() error {
+	if err := r.jh.Format(); err != nil {
+		return err
+	}
+	return r.jh.SaveTo(r.path)
+}
+
+type Rule struct {
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Outbound string `json:"outbound"`
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("%s,%s,%s", r.Type, r.Value, r.Outbound)
+}
+
+// 检查规则类型和值
+func (r Rule) Check() error {
+	if !slices.Contains(Types, r.Type) {
+		return fmt.Errorf("unsupport rule type '%s', available types: %s", r.Type, strings.Join(Types, ", "))
+	}
+	if r.Value == "" {
+		return errors.New("rule value is empty")
+	}
+	if r.Outbound == "" {
+		return errors.New("rule outbound is empty")
+	}
+	if r.Type == "ip_cidr" {
+		if _, err := netip.ParsePrefix(r.Value); err != nil {
+			if _, err := netip.ParseAddr(r.Value); err != nil {
+				return fmt.Errorf("invalid ip cidr '%s'", r.Value)
+			}
+		}
+	}
+	return nil
+}
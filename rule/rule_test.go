@@ -0,0 +1,43 @@
+package rule_test
+
+import (
+	"testing"
+
+	"github.com/follow1123/sing-box-ctl/config"
+	"github.com/follow1123/sing-box-ctl/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAndDelete(t *testing.T) {
+	conf, err := config.New(t.TempDir())
+	require.NoError(t, err)
+	r, err := rule.New(conf.ConfigPath())
+	require.NoError(t, err)
+	list, err := r.List()
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	require.NoError(t, r.Add(rule.Rule{Type: "domain_suffix", Value: "example.com", Outbound: "直连"}))
+	require.NoError(t, r.Add(rule.Rule{Type: "ip_cidr", Value: "10.1.0.0/16", Outbound: "节点选择"}))
+	require.NoError(t, r.Add(rule.Rule{Type: "process_name", Value: "curl", Outbound: "reject"}))
+	require.ErrorContains(t, r.Add(rule.Rule{Type: "process_name", Value: "curl", Outbound: "reject"}), "duplicate rule")
+	require.NoError(t, r.Save())
+
+	r, err = rule.New(conf.ConfigPath())
+	require.NoError(t, err)
+	require.NoError(t, r.Delete(2))
+	require.ErrorContains(t, r.Delete(3), "rule 3 not exists")
+	list, err = r.List()
+	require.NoError(t, err)
+	require.Equal(t, []rule.Rule{
+		{Type: "domain_suffix", Value: "example.com", Outbound: "直连"},
+		{Type: "process_name", Value: "curl", Outbound: "reject"},
+	}, list)
+}
+
+func TestCheck(t *testing.T) {
+	require.NoError(t, rule.Rule{Type: "ip_cidr", Value: "1.1.1.1", Outbound: "a"}.Check())
+	require.ErrorContains(t, rule.Rule{Type: "geoip", Value: "cn", Outbound: "a"}.Check(), "unsupport rule type 'geoip'")
+	require.ErrorContains(t, rule.Rule{Type: "ip_cidr", Value: "1.1.1", Outbound: "a"}.Check(), "invalid ip cidr")
+	require.ErrorContains(t, rule.Rule{Type: "domain", Value: "a.com"}.Check(), "outbound is empty")
+}
@@ -0,0 +1,81 @@
+package singbox
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// sing-box 配置，只包含本项目用到的字段
+type Config struct {
+	Log          *Log          `json:"log,omitempty"`
+	Experimental *Experimental `json:"experimental,omitempty"`
+	DNS          *DNS          `json:"dns,omitempty"`
+	Inbounds     []Inbound     `json:"inbounds,omitempty"`
+	Endpoints    []Endpoint    `json:"endpoints,omitempty"`
+	Outbounds    []Outbound    `json:"outbounds,omitempty"`
+	Route        *Route        `json:"route,omitempty"`
+}
+
+type Log struct {
+	Disabled  bool   `json:"disabled"`
+	Level     string `json:"level,omitempty"`
+	Output    string `json:"output,omitempty"`
+	Timestamp bool   `json:"timestamp"`
+}
+
+type Experimental struct {
+	ClashApi  *ClashApi  `json:"clash_api,omitempty"`
+	CacheFile *CacheFile `json:"cache_file,omitempty"`
+}
+
+type ClashApi struct {
+	ExternalController       string `json:"external_controller,omitempty"`
+	ExternalUi               string `json:"external_ui,omitempty"`
+	ExternalUiDownloadDetour string `json:"external_ui_download_detour,omitempty"`
+	Secret                   string `json:"secret"`
+	DefaultMode              string `json:"default_mode,omitempty"`
+}
+
+type CacheFile struct {
+	Enabled bool `json:"enabled"`
+}
+
+// 转换为格式化的 json，不转义 html 字符
+func (c *Config) MarshalIndent() ([]byte, error) {
+	return marshal(c, "  ")
+}
+
+// 单个值输出为值本身，多个值输出为数组，和 sing-box 的 badoption.Listable 一致
+type Listable[T any] []T
+
+func (l Listable[T]) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]T(l))
+}
+
+func (l *Listable[T]) UnmarshalJSON(data []byte) error {
+	var single T
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = Listable[T]{single}
+		return nil
+	}
+	var list []T
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+func marshal(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,38 @@
+package singbox
+
+type DNS struct {
+	Servers      []DNSServer `json:"servers,omitempty"`
+	Rules        []DNSRule   `json:"rules,omitempty"`
+	Strategy     string      `json:"strategy,omitempty"`
+	Final        string      `json:"final,omitempty"`
+	ClientSubnet string      `json:"client_subnet,omitempty"`
+}
+
+// sing-box 1.12 开始的 dns 服务器格式，Type 为 udp、tcp、https 等
+type DNSServer struct {
+	Type           string `json:"type"`
+	Tag            string `json:"tag"`
+	Server         string `json:"server,omitempty"`
+	ServerPort     int    `json:"server_port,omitempty"`
+	Path           string `json:"path,omitempty"`
+	DomainResolver string `json:"domain_resolver,omitempty"`
+	Detour         string `json:"detour,omitempty"`
+
+	// dhcp 服务器使用的网卡
+	Interface string `json:"interface,omitempty"`
+	// hosts 服务器预定义的域名和地址
+	Predefined map[string]Listable[string] `json:"predefined,omitempty"`
+	// fakeip 服务器的地址范围
+	Inet4Range string `json:"inet4_range,omitempty"`
+	Inet6Range string `json:"inet6_range,omitempty"`
+}
+
+type DNSRule struct {
+	ClashMode    string           `json:"clash_mode,omitempty"`
+	QueryType    Listable[string] `json:"query_type,omitempty"`
+	Domain       Listable[string] `json:"domain,omitempty"`
+	DomainSuffix Listable[string] `json:"domain_suffix,omitempty"`
+	DomainRegex  Listable[string] `json:"domain_regex,omitempty"`
+	RuleSet      Listable[string] `json:"rule_set,omitempty"`
+	Server       string           `json:"server,omitempty"`
+}
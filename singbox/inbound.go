@@ -0,0 +1,30 @@
+package singbox
+
+type Inbound struct {
+	Type    string
+	Tag     string
+	Options any
+}
+
+func (i Inbound) MarshalJSON() ([]byte, error) {
+	return marshalTyped(i.Type, i.Tag, i.Options)
+}
+
+func (i *Inbound) UnmarshalJSON(data []byte) error {
+	var err error
+	i.Type, i.Tag, i.Options, err = unmarshalTyped(data)
+	return err
+}
+
+type Mixed struct {
+	Listen         string `json:"listen,omitempty"`
+	ListenPort     int    `json:"listen_port,omitempty"`
+	SetSystemProxy bool   `json:"set_system_proxy"`
+}
+
+type Tun struct {
+	InterfaceName string           `json:"interface_name,omitempty"`
+	Address       Listable[string] `json:"address,omitempty"`
+	Mtu           int              `json:"mtu,omitempty"`
+	AutoRoute     bool             `json:"auto_route,omitempty"`
+}
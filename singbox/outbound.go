@@ -0,0 +1,288 @@
+package singbox
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Options 为 type 和 tag 之外的字段，使用协议对应的结构体，或者 json.RawMessage 原样输出
+type Outbound struct {
+	Type    string
+	Tag     string
+	Options any
+}
+
+func (o Outbound) MarshalJSON() ([]byte, error) {
+	return marshalTyped(o.Type, o.Tag, o.Options)
+}
+
+func (o *Outbound) UnmarshalJSON(data []byte) error {
+	var err error
+	o.Type, o.Tag, o.Options, err = unmarshalTyped(data)
+	return err
+}
+
+// sing-box 1.11 开始 wireguard 等协议作为 endpoint 配置
+type Endpoint struct {
+	Type    string
+	Tag     string
+	Options any
+}
+
+func (e Endpoint) MarshalJSON() ([]byte, error) {
+	return marshalTyped(e.Type, e.Tag, e.Options)
+}
+
+func (e *Endpoint) UnmarshalJSON(data []byte) error {
+	var err error
+	e.Type, e.Tag, e.Options, err = unmarshalTyped(data)
+	return err
+}
+
+// 将 type、tag 和 Options 的字段合并为一个 json 对象，type 和 tag 在最前面
+func marshalTyped(typ string, tag string, options any) ([]byte, error) {
+	header, err := marshal(struct {
+		Type string `json:"type"`
+		Tag  string `json:"tag,omitempty"`
+	}{typ, tag}, "")
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		return header, nil
+	}
+	fields, err := marshal(options, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < 2 || fields[0] != '{' {
+		return nil, errors.New("options must be json object")
+	}
+	if string(fields) == "{}" {
+		return header, nil
+	}
+	result := append(header[:len(header)-1], ',')
+	return append(result, fields[1:]...), nil
+}
+
+// 解析时 Options 为除 type 和 tag 外的字段
+func unmarshalTyped(data []byte) (string, string, any, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", "", nil, err
+	}
+	var typ, tag string
+	if raw, ok := fields["type"]; ok {
+		if err := json.Unmarshal(raw, &typ); err != nil {
+			return "", "", nil, err
+		}
+	}
+	if raw, ok := fields["tag"]; ok {
+		if err := json.Unmarshal(raw, &tag); err != nil {
+			return "", "", nil, err
+		}
+	}
+	delete(fields, "type")
+	delete(fields, "tag")
+	if len(fields) == 0 {
+		return typ, tag, nil, nil
+	}
+	options, err := marshal(fields, "")
+	if err != nil {
+		return "", "", nil, err
+	}
+	return typ, tag, json.RawMessage(options), nil
+}
+
+type ServerOptions struct {
+	Server     string `json:"server"`
+	ServerPort int    `json:"server_port"`
+	// 拨号字段，通过其他 outbound 连接服务器
+	Detour string `json:"detour,omitempty"`
+}
+
+type Shadowsocks struct {
+	ServerOptions
+	Method     string `json:"method"`
+	Password   string `json:"password"`
+	Plugin     string `json:"plugin,omitempty"`
+	PluginOpts string `json:"plugin_opts,omitempty"`
+	Network    string `json:"network,omitempty"`
+}
+
+// shadow-tls 只负责握手，需要其他协议的 outbound 通过 detour 使用
+type ShadowTls struct {
+	ServerOptions
+	Version  int    `json:"version,omitempty"`
+	Password string `json:"password,omitempty"`
+	Tls      *Tls   `json:"tls"`
+}
+
+type Trojan struct {
+	ServerOptions
+	Password  string     `json:"password"`
+	Network   string     `json:"network,omitempty"`
+	Tls       *Tls       `json:"tls,omitempty"`
+	Transport *Transport `json:"transport,omitempty"`
+}
+
+type Vmess struct {
+	ServerOptions
+	Uuid      string     `json:"uuid"`
+	Security  string     `json:"security,omitempty"`
+	AlterId   int        `json:"alter_id"`
+	Network   string     `json:"network,omitempty"`
+	Tls       *Tls       `json:"tls,omitempty"`
+	Transport *Transport `json:"transport,omitempty"`
+}
+
+type Vless struct {
+	ServerOptions
+	Uuid           string     `json:"uuid"`
+	Flow           string     `json:"flow,omitempty"`
+	Network        string     `json:"network,omitempty"`
+	PacketEncoding string     `json:"packet_encoding,omitempty"`
+	Tls            *Tls       `json:"tls,omitempty"`
+	Transport      *Transport `json:"transport,omitempty"`
+}
+
+type Hysteria2 struct {
+	ServerOptions
+	ServerPorts []string `json:"server_ports,omitempty"`
+	HopInterval string   `json:"hop_interval,omitempty"`
+	UpMbps      int      `json:"up_mbps,omitempty"`
+	DownMbps    int      `json:"down_mbps,omitempty"`
+	Obfs        *Obfs    `json:"obfs,omitempty"`
+	Password    string   `json:"password,omitempty"`
+	Tls         *Tls     `json:"tls,omitempty"`
+}
+
+type Obfs struct {
+	Type     string `json:"type"`
+	Password string `json:"password"`
+}
+
+type Tuic struct {
+	ServerOptions
+	Uuid              string `json:"uuid"`
+	Password          string `json:"password,omitempty"`
+	CongestionControl string `json:"congestion_control,omitempty"`
+	UdpRelayMode      string `json:"udp_relay_mode,omitempty"`
+	ZeroRttHandshake  bool   `json:"zero_rtt_handshake,omitempty"`
+	Heartbeat         string `json:"heartbeat,omitempty"`
+	Tls               *Tls   `json:"tls,omitempty"`
+}
+
+type Http struct {
+	ServerOptions
+	Username string     `json:"username,omitempty"`
+	Password string     `json:"password,omitempty"`
+	Headers  HttpHeader `json:"headers,omitempty"`
+	Tls      *Tls       `json:"tls,omitempty"`
+}
+
+type Socks struct {
+	ServerOptions
+	Version  string `json:"version,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Network  string `json:"network,omitempty"`
+}
+
+type AnyTls struct {
+	ServerOptions
+	Password                 string `json:"password"`
+	IdleSessionCheckInterval string `json:"idle_session_check_interval,omitempty"`
+	IdleSessionTimeout       string `json:"idle_session_timeout,omitempty"`
+	MinIdleSession           int    `json:"min_idle_session,omitempty"`
+	Tls                      *Tls   `json:"tls"`
+}
+
+// 没有设置 HostKey 时不校验服务器的公钥
+type Ssh struct {
+	ServerOptions
+	User                 string           `json:"user,omitempty"`
+	Password             string           `json:"password,omitempty"`
+	PrivateKey           Listable[string] `json:"private_key,omitempty"`
+	PrivateKeyPath       string           `json:"private_key_path,omitempty"`
+	PrivateKeyPassphrase string           `json:"private_key_passphrase,omitempty"`
+	HostKey              []string         `json:"host_key,omitempty"`
+	HostKeyAlgorithms    []string         `json:"host_key_algorithms,omitempty"`
+}
+
+type Selector struct {
+	Outbounds                 []string `json:"outbounds"`
+	Default                   string   `json:"default,omitempty"`
+	InterruptExistConnections bool     `json:"interrupt_exist_connections"`
+}
+
+type UrlTest struct {
+	Outbounds                 []string `json:"outbounds"`
+	Url                       string   `json:"url,omitempty"`
+	Interval                  string   `json:"interval,omitempty"`
+	Tolerance                 int      `json:"tolerance,omitempty"`
+	InterruptExistConnections bool     `json:"interrupt_exist_connections"`
+}
+
+type WireGuard struct {
+	Mtu        int             `json:"mtu,omitempty"`
+	Address    []string        `json:"address"`
+	PrivateKey string          `json:"private_key"`
+	Peers      []WireGuardPeer `json:"peers"`
+	Detour     string          `json:"detour,omitempty"`
+}
+
+type WireGuardPeer struct {
+	Address                     string   `json:"address"`
+	Port                        int      `json:"port"`
+	PublicKey                   string   `json:"public_key"`
+	PreSharedKey                string   `json:"pre_shared_key,omitempty"`
+	AllowedIps                  []string `json:"allowed_ips"`
+	PersistentKeepaliveInterval int      `json:"persistent_keepalive_interval,omitempty"`
+	Reserved                    []int    `json:"reserved,omitempty"`
+}
+
+type Tls struct {
+	Enabled    bool     `json:"enabled"`
+	ServerName string   `json:"server_name,omitempty"`
+	Insecure   bool     `json:"insecure,omitempty"`
+	Alpn       []string `json:"alpn,omitempty"`
+	// 信任的服务器证书，PEM 格式的每一行或者文件路径
+	Certificate     []string `json:"certificate,omitempty"`
+	CertificatePath string   `json:"certificate_path,omitempty"`
+	Ech             *Ech     `json:"ech,omitempty"`
+	Utls            *Utls    `json:"utls,omitempty"`
+	Reality         *Reality `json:"reality,omitempty"`
+}
+
+// 没有配置时通过 dns 查询 ECH 配置
+type Ech struct {
+	Enabled    bool     `json:"enabled"`
+	Config     []string `json:"config,omitempty"`
+	ConfigPath string   `json:"config_path,omitempty"`
+}
+
+type Utls struct {
+	Enabled     bool   `json:"enabled"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+type Reality struct {
+	Enabled   bool   `json:"enabled"`
+	PublicKey string `json:"public_key"`
+	ShortId   string `json:"short_id,omitempty"`
+}
+
+// v2ray 传输层，Type 为 ws、http、grpc、httpupgrade
+type Transport struct {
+	Type                string           `json:"type"`
+	Host                Listable[string] `json:"host,omitempty"`
+	Path                string           `json:"path,omitempty"`
+	Method              string           `json:"method,omitempty"`
+	Headers             HttpHeader       `json:"headers,omitempty"`
+	ServiceName         string           `json:"service_name,omitempty"`
+	MaxEarlyData        int              `json:"max_early_data,omitempty"`
+	EarlyDataHeaderName string           `json:"early_data_header_name,omitempty"`
+}
+
+type HttpHeader map[string]Listable[string]
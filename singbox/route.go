@@ -0,0 +1,59 @@
+package singbox
+
+type Route struct {
+	Rules                 []Rule    `json:"rules,omitempty"`
+	DefaultDomainResolver string    `json:"default_domain_resolver,omitempty"`
+	AutoDetectInterface   bool      `json:"auto_detect_interface,omitempty"`
+	Final                 string    `json:"final,omitempty"`
+	RuleSet               []RuleSet `json:"rule_set,omitempty"`
+}
+
+// 路由规则，Type 为 logical 时是逻辑规则，Action 为空时默认为 route
+type Rule struct {
+	Type        string           `json:"type,omitempty"`
+	Mode        string           `json:"mode,omitempty"`
+	Rules       []Rule           `json:"rules,omitempty"`
+	Protocol    Listable[string] `json:"protocol,omitempty"`
+	Port        Listable[uint16] `json:"port,omitempty"`
+	PortRange   Listable[string] `json:"port_range,omitempty"`
+	IpIsPrivate bool             `json:"ip_is_private,omitempty"`
+	ClashMode   string           `json:"clash_mode,omitempty"`
+	RuleSet     Listable[string] `json:"rule_set,omitempty"`
+	Invert      bool             `json:"invert,omitempty"`
+	Action      string           `json:"action,omitempty"`
+	Outbound    string           `json:"outbound,omitempty"`
+	Method      string           `json:"method,omitempty"`
+	Sniffer     Listable[string] `json:"sniffer,omitempty"`
+}
+
+// 规则集中的规则，Type 为 logical 时是逻辑规则
+type HeadlessRule struct {
+	Type            string           `json:"type,omitempty"`
+	Mode            string           `json:"mode,omitempty"`
+	Rules           []HeadlessRule   `json:"rules,omitempty"`
+	Network         Listable[string] `json:"network,omitempty"`
+	Domain          Listable[string] `json:"domain,omitempty"`
+	DomainSuffix    Listable[string] `json:"domain_suffix,omitempty"`
+	DomainKeyword   Listable[string] `json:"domain_keyword,omitempty"`
+	DomainRegex     Listable[string] `json:"domain_regex,omitempty"`
+	SourceIpCidr    Listable[string] `json:"source_ip_cidr,omitempty"`
+	IpCidr          Listable[string] `json:"ip_cidr,omitempty"`
+	SourcePort      Listable[uint16] `json:"source_port,omitempty"`
+	SourcePortRange Listable[string] `json:"source_port_range,omitempty"`
+	Port            Listable[uint16] `json:"port,omitempty"`
+	PortRange       Listable[string] `json:"port_range,omitempty"`
+	ProcessName     Listable[string] `json:"process_name,omitempty"`
+	ProcessPath     Listable[string] `json:"process_path,omitempty"`
+	Invert          bool             `json:"invert,omitempty"`
+}
+
+// Type 为 inline 时使用 Rules，为 remote 时使用 Url
+type RuleSet struct {
+	Type           string         `json:"type"`
+	Tag            string         `json:"tag"`
+	Format         string         `json:"format,omitempty"`
+	Url            string         `json:"url,omitempty"`
+	DownloadDetour string         `json:"download_detour,omitempty"`
+	UpdateInterval string         `json:"update_interval,omitempty"`
+	Rules          []HeadlessRule `json:"rules,omitempty"`
+}
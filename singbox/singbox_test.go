@@ -0,0 +1,89 @@
+package singbox_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/follow1123/sing-box-ctl/singbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboundMarshal(t *testing.T) {
+	t.Run("options", func(t *testing.T) {
+		data, err := json.Marshal(singbox.Outbound{
+			Type: "shadowsocks",
+			Tag:  "ss",
+			Options: &singbox.Shadowsocks{
+				ServerOptions: singbox.ServerOptions{Server: "a.com", ServerPort: 8388},
+				Method:        "aes-128-gcm",
+				Password:      "123456",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"shadowsocks","tag":"ss","server":"a.com","server_port":8388,"method":"aes-128-gcm","password":"123456"}`, string(data))
+	})
+	t.Run("no options", func(t *testing.T) {
+		data, err := json.Marshal(singbox.Outbound{Type: "direct", Tag: "direct"})
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"direct","tag":"direct"}`, string(data))
+	})
+	t.Run("raw options", func(t *testing.T) {
+		data, err := json.Marshal(singbox.Outbound{Type: "anytls", Tag: "a", Options: json.RawMessage(`{"server":"a.com"}`)})
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"anytls","tag":"a","server":"a.com"}`, string(data))
+	})
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := json.Marshal(singbox.Outbound{Type: "direct", Tag: "direct", Options: "direct"})
+		assert.Error(t, err)
+	})
+}
+
+func TestOutboundUnmarshal(t *testing.T) {
+	var ob singbox.Outbound
+	err := json.Unmarshal([]byte(`{"type":"vless","tag":"v","server":"v.com","server_port":443}`), &ob)
+	require.NoError(t, err)
+	assert.Equal(t, "vless", ob.Type)
+	assert.Equal(t, "v", ob.Tag)
+	assert.JSONEq(t, `{"server":"v.com","server_port":443}`, string(ob.Options.(json.RawMessage)))
+
+	data, err := json.Marshal(ob)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"vless","tag":"v","server":"v.com","server_port":443}`, string(data))
+}
+
+func TestListable(t *testing.T) {
+	data, err := json.Marshal(singbox.HeadlessRule{
+		Domain:    singbox.Listable[string]{"a.com"},
+		IpCidr:    singbox.Listable[string]{"10.0.0.0/8", "172.16.0.0/12"},
+		Port:      singbox.Listable[uint16]{443},
+		PortRange: nil,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"domain":"a.com","ip_cidr":["10.0.0.0/8","172.16.0.0/12"],"port":443}`, string(data))
+
+	var rule singbox.HeadlessRule
+	require.NoError(t, json.Unmarshal([]byte(`{"domain":"a.com","ip_cidr":["10.0.0.0/8"]}`), &rule))
+	assert.Equal(t, singbox.Listable[string]{"a.com"}, rule.Domain)
+	assert.Equal(t, singbox.Listable[string]{"10.0.0.0/8"}, rule.IpCidr)
+}
+
+func TestConfigMarshalIndent(t *testing.T) {
+	config := &singbox.Config{
+		Outbounds: []singbox.Outbound{{Type: "direct", Tag: "<直连>"}},
+		Route:     &singbox.Route{Final: "<直连>"},
+	}
+	data, err := config.MarshalIndent()
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "outbounds": [
+    {
+      "type": "direct",
+      "tag": "<直连>"
+    }
+  ],
+  "route": {
+    "final": "<直连>"
+  }
+}`, string(data))
+}
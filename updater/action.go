@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	JH "github.com/follow1123/sing-box-ctl/jsonhandler"
+	"github.com/follow1123/sing-box-ctl/singbox"
 )
 
 type ActionKey string
@@ -92,13 +93,12 @@ func (w *WebUIStatusAction) Update(jsonHandler *JH.JsonHandler) error {
 		return errors.New("invalid value type, should be bool")
 	}
 	if status {
-		return jsonHandler.SetRaw(w.path, []byte(`{
-  "external_controller": "127.0.0.1:9090",
-  "external_ui": "./ui/",
-  "external_ui_download_detour": "节点选择",
-  "secret": "",
-  "default_mode": "rule"
-}`))
+		return jsonHandler.Set(w.path, singbox.ClashApi{
+			ExternalController:       "127.0.0.1:9090",
+			ExternalUi:               "./ui/",
+			ExternalUiDownloadDetour: "节点选择",
+			DefaultMode:              "rule",
+		})
 	} else {
 		return jsonHandler.Delete(w.path)
 	}
@@ -215,13 +215,14 @@ func (w *MixedModeAction) Get(jsonHandler *JH.JsonHandler) (any, error) {
 }
 
 func (w *MixedModeAction) Update(jsonHandler *JH.JsonHandler) error {
-	return jsonHandler.SetRaw(w.path, []byte(`{
-  "type": "mixed",
-  "tag": "mixed-in",
-  "listen": "127.0.0.1",
-  "listen_port": 7899,
-  "set_system_proxy": false
-}`))
+	return jsonHandler.Set(w.path, singbox.Inbound{
+		Type: "mixed",
+		Tag:  "mixed-in",
+		Options: &singbox.Mixed{
+			Listen:     "127.0.0.1",
+			ListenPort: 7899,
+		},
+	})
 }
 
 func (w *MixedModeAction) IsEnabled(jsonHandler *JH.JsonHandler) (bool, error) {
@@ -410,14 +411,16 @@ func (w *TunModeAction) Get(jsonHandler *JH.JsonHandler) (any, error) {
 }
 
 func (w *TunModeAction) Update(jsonHandler *JH.JsonHandler) error {
-	return jsonHandler.SetRaw(w.path, []byte(`{
-  "type": "tun",
-  "tag": "tun-in",
-  "interface_name": "tun0",
-  "address": "172.18.0.1/30",
-  "mtu": 9000,
-  "auto_route": true
-}`))
+	return jsonHandler.Set(w.path, singbox.Inbound{
+		Type: "tun",
+		Tag:  "tun-in",
+		Options: &singbox.Tun{
+			InterfaceName: "tun0",
+			Address:       singbox.Listable[string]{"172.18.0.1/30"},
+			Mtu:           9000,
+			AutoRoute:     true,
+		},
+	})
 }
 
 func (w *TunModeAction) IsEnabled(jsonHandler *JH.JsonHandler) (bool, error) {
@@ -66,7 +66,17 @@ func (u *Updater) Update(actions []Action, format bool) error {
 	return err
 }
 
+// 使用新的配置，保留原配置中 web ui 和入站的设置
 func (u *Updater) Upgrade(data []byte, format bool) error {
+	return u.upgrade(data, format, false, false)
+}
+
+// 和 Upgrade 相同，但是 mixed 入站按参数使用新配置中的端口或监听地址，其他设置仍然保留
+func (u *Updater) UpgradeWithListen(data []byte, format bool, port bool, allowLAN bool) error {
+	return u.upgrade(data, format, port, allowLAN)
+}
+
+func (u *Updater) upgrade(data []byte, format bool, newPort bool, newAllowLAN bool) error {
 	var actions []Action
 	webUIStatusAct := NewWebUIStatusAction()
 	if webUIStatusAct.IsEnabled(u.jsonHandler) {
@@ -94,13 +104,24 @@ func (u *Updater) Upgrade(data []byte, format bool) error {
 	}
 	switch inboundType {
 	case "mixed":
-		mixedPortAct := NewMixedPortAction()
-		port, err := mixedPortAct.GetPort(u.jsonHandler)
-		if err != nil {
-			return err
+		if !newPort {
+			mixedPortAct := NewMixedPortAction()
+			port, err := mixedPortAct.GetPort(u.jsonHandler)
+			if err != nil {
+				return err
+			}
+			mixedPortAct.SetValue(port)
+			actions = append(actions, mixedPortAct)
+		}
+		if !newAllowLAN {
+			mixedAllowLANAct := NewMixedAllowLANAction()
+			isAllowLAN, err := mixedAllowLANAct.IsAllowLAN(u.jsonHandler)
+			if err != nil {
+				return err
+			}
+			mixedAllowLANAct.SetValue(isAllowLAN)
+			actions = append(actions, mixedAllowLANAct)
 		}
-		mixedPortAct.SetValue(port)
-		actions = append(actions, mixedPortAct)
 		mixedSysProxyAct := NewMixedSysProxyAction()
 		isSysProxyEnabled, err := mixedSysProxyAct.IsSysProxyEnabled(u.jsonHandler)
 		if err != nil {
@@ -108,13 +129,6 @@ func (u *Updater) Upgrade(data []byte, format bool) error {
 		}
 		mixedSysProxyAct.SetValue(isSysProxyEnabled)
 		actions = append(actions, mixedSysProxyAct)
-		mixedAllowLANAct := NewMixedAllowLANAction()
-		isAllowLAN, err := mixedAllowLANAct.IsAllowLAN(u.jsonHandler)
-		if err != nil {
-			return err
-		}
-		mixedAllowLANAct.SetValue(isAllowLAN)
-		actions = append(actions, mixedAllowLANAct)
 	case "tun":
 		actions = append(actions, NewTunModeAction())
 	default:
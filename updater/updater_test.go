@@ -254,6 +254,70 @@ func TestUpgradeFailure(t *testing.T) {
 	require.ErrorContains(t, err, "unsupported inbound type")
 }
 
+func TestUpgradeWithListen(t *testing.T) {
+	newData := []byte(`{
+		"inbounds": [
+			{ "type": "mixed", "tag": "mixed-in", "listen": "::", "listen_port": 7890, "set_system_proxy": false }
+		],
+		"outbounds": [{ "type": "direct", "tag": "直连" }]
+	}`)
+	t.Run("keep listen settings", func(t *testing.T) {
+		u, err := updater.FromData(configData)
+		require.NoError(t, err)
+		require.NoError(t, u.Upgrade(newData, false))
+		jh, err := JH.FromData(u.Data())
+		require.NoError(t, err)
+		port, err := updater.NewMixedPortAction().GetPort(jh)
+		require.NoError(t, err)
+		require.Equal(t, uint16(7899), port)
+		isAllowLAN, err := updater.NewMixedAllowLANAction().IsAllowLAN(jh)
+		require.NoError(t, err)
+		require.False(t, isAllowLAN)
+	})
+	t.Run("use new listen settings", func(t *testing.T) {
+		u, err := updater.FromData(configData)
+		require.NoError(t, err)
+		require.NoError(t, u.UpgradeWithListen(newData, false, true, true))
+		jh, err := JH.FromData(u.Data())
+		require.NoError(t, err)
+		port, err := updater.NewMixedPortAction().GetPort(jh)
+		require.NoError(t, err)
+		require.Equal(t, uint16(7890), port)
+		isAllowLAN, err := updater.NewMixedAllowLANAction().IsAllowLAN(jh)
+		require.NoError(t, err)
+		require.True(t, isAllowLAN)
+		isSysProxyEnabled, err := updater.NewMixedSysProxyAction().IsSysProxyEnabled(jh)
+		require.NoError(t, err)
+		require.True(t, isSysProxyEnabled)
+	})
+	t.Run("use new port only", func(t *testing.T) {
+		u, err := updater.FromData(configData)
+		require.NoError(t, err)
+		require.NoError(t, u.UpgradeWithListen(newData, false, true, false))
+		jh, err := JH.FromData(u.Data())
+		require.NoError(t, err)
+		port, err := updater.NewMixedPortAction().GetPort(jh)
+		require.NoError(t, err)
+		require.Equal(t, uint16(7890), port)
+		isAllowLAN, err := updater.NewMixedAllowLANAction().IsAllowLAN(jh)
+		require.NoError(t, err)
+		require.False(t, isAllowLAN)
+	})
+	t.Run("use new allow lan only", func(t *testing.T) {
+		u, err := updater.FromData(configData)
+		require.NoError(t, err)
+		require.NoError(t, u.UpgradeWithListen(newData, false, false, true))
+		jh, err := JH.FromData(u.Data())
+		require.NoError(t, err)
+		port, err := updater.NewMixedPortAction().GetPort(jh)
+		require.NoError(t, err)
+		require.Equal(t, uint16(7899), port)
+		isAllowLAN, err := updater.NewMixedAllowLANAction().IsAllowLAN(jh)
+		require.NoError(t, err)
+		require.True(t, isAllowLAN)
+	})
+}
+
 func TestSave(t *testing.T) {
 	t.Run("update and save config", func(t *testing.T) {
 		var buf bytes.Buffer